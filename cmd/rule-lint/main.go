@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine"
+	"io/ioutil"
+	"os"
+)
+
+func main() {
+	jsonOutput := flag.Bool("json", false, "print findings as JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-json] ruleset.json...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	exitCode := 0
+	reports := make(map[string]ruleengine.AnalysisReport)
+	for _, file := range flag.Args() {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			os.Exit(2)
+		}
+		report, err := ruleengine.AnalyzeJsonRuleSet(string(data))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			os.Exit(2)
+		}
+		if report.HasFindings() {
+			exitCode = 1
+		}
+		reports[file] = report
+		if *jsonOutput {
+			continue
+		}
+		for _, finding := range report.Findings {
+			fmt.Printf("%s: %s: %s: %s\n", file, finding.Path, finding.Kind, finding.Message)
+		}
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(reports)
+	}
+	os.Exit(exitCode)
+}
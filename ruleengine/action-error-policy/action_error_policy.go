@@ -0,0 +1,7 @@
+package actionerrorpolicies
+
+const (
+	Continue    = "CONTINUE"
+	StopActions = "STOP_ACTIONS"
+	FailResult  = "FAIL_RESULT"
+)
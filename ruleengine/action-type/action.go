@@ -3,4 +3,9 @@ package actiontypes
 const (
 	ReplaceString = "ReplaceString"
 	ReturnValue   = "ReturnValue"
+	SetValue      = "SetValue"
+	DeleteField   = "DeleteField"
+	Rename        = "Rename"
+	Template      = "Template"
+	Compute       = "Compute"
 )
@@ -1,7 +1,11 @@
 package ruleengine
 
 type ActionParams struct {
-	Name        string `json:"name,omitempty"`
-	Pattern     string `json:"pattern,omitempty"`
-	Replacement string `json:"replacement,omitempty"`
+	Name        string      `json:"name,omitempty"`
+	Pattern     string      `json:"pattern,omitempty"`
+	Replacement string      `json:"replacement,omitempty"`
+	Value       interface{} `json:"value,omitempty"`
+	Target      string      `json:"target,omitempty"`
+	Template    string      `json:"template,omitempty"`
+	Expression  string      `json:"expression,omitempty"`
 }
@@ -0,0 +1,10 @@
+package aggregatefunctions
+
+const (
+	Sum           = "sum"
+	Avg           = "avg"
+	Min           = "min"
+	Max           = "max"
+	Count         = "count"
+	DistinctCount = "distinct_count"
+)
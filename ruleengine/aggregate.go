@@ -0,0 +1,216 @@
+package ruleengine
+
+import (
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/aggregate-function"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/decimal"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/field-type"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// aggregateField is a field expression such as `sum(items[*].price)` that
+// aggregates the values found by following a path through nested arrays.
+type aggregateField struct {
+	function string
+	path     []pathSegment
+}
+
+type pathSegment struct {
+	name string
+	each bool
+}
+
+type aggregateCache struct {
+	mu     sync.RWMutex
+	fields map[string]aggregateField
+}
+
+func newAggregateCache() *aggregateCache {
+	return &aggregateCache{
+		fields: make(map[string]aggregateField),
+	}
+}
+
+func (c *aggregateCache) get(text string) (aggregateField, error) {
+	c.mu.RLock()
+	field, ok := c.fields[text]
+	c.mu.RUnlock()
+	if ok {
+		return field, nil
+	}
+	field, err := parseAggregateField(text)
+	if err != nil {
+		return aggregateField{}, err
+	}
+	c.mu.Lock()
+	c.fields[text] = field
+	c.mu.Unlock()
+	return field, nil
+}
+
+func isAggregateField(name string) bool {
+	return strings.HasSuffix(name, ")") && strings.Contains(name, "(")
+}
+
+func parseAggregateField(text string) (aggregateField, error) {
+	open := strings.IndexByte(text, '(')
+	if open < 0 || !strings.HasSuffix(text, ")") {
+		return aggregateField{}, fmt.Errorf("invalid aggregate field %q", text)
+	}
+	field := aggregateField{function: strings.TrimSpace(text[:open])}
+	switch field.function {
+	case aggregatefunctions.Sum, aggregatefunctions.Avg, aggregatefunctions.Min, aggregatefunctions.Max, aggregatefunctions.Count, aggregatefunctions.DistinctCount:
+	default:
+		return aggregateField{}, fmt.Errorf("unknown aggregate function %q in %q", field.function, text)
+	}
+	for _, part := range strings.Split(strings.TrimSpace(text[open+1:len(text)-1]), ".") {
+		segment := pathSegment{name: part}
+		if strings.HasSuffix(part, "[*]") {
+			segment = pathSegment{name: strings.TrimSuffix(part, "[*]"), each: true}
+		}
+		if segment.name == "" || strings.ContainsAny(segment.name, "[]()* ") {
+			return aggregateField{}, fmt.Errorf("invalid path segment %q in %q", part, text)
+		}
+		field.path = append(field.path, segment)
+	}
+	return field, nil
+}
+
+func (f aggregateField) resultType() string {
+	if f.function == aggregatefunctions.Count || f.function == aggregatefunctions.DistinctCount {
+		return fieldtypes.Integer
+	}
+	return fieldtypes.Number
+}
+
+// collect follows the path from the input, expanding every `[*]` segment and any
+// array found at the end of the path. Missing and nil values are skipped.
+func (f aggregateField) collect(input map[string]interface{}) []interface{} {
+	values := []interface{}{input}
+	for _, segment := range f.path {
+		var next []interface{}
+		for _, value := range values {
+			field, ok := lookupField(value, segment.name)
+			if !ok || field == nil {
+				continue
+			}
+			if segment.each {
+				next = appendElements(next, field)
+			} else {
+				next = append(next, field)
+			}
+		}
+		values = next
+	}
+	var result []interface{}
+	for _, value := range values {
+		result = appendElements(result, value)
+	}
+	return result
+}
+
+func lookupField(value interface{}, name string) (interface{}, bool) {
+	if fields, ok := value.(map[string]interface{}); ok {
+		field, ok := fields[name]
+		return field, ok
+	}
+	fields := reflect.ValueOf(value)
+	if fields.Kind() != reflect.Map || fields.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	field := fields.MapIndex(reflect.ValueOf(name).Convert(fields.Type().Key()))
+	if !field.IsValid() {
+		return nil, false
+	}
+	return field.Interface(), true
+}
+
+func appendElements(values []interface{}, value interface{}) []interface{} {
+	elements := reflect.ValueOf(value)
+	if elements.Kind() != reflect.Slice && elements.Kind() != reflect.Array {
+		if value == nil {
+			return values
+		}
+		return append(values, value)
+	}
+	for i := 0; i < elements.Len(); i++ {
+		if element := elements.Index(i).Interface(); element != nil {
+			values = append(values, element)
+		}
+	}
+	return values
+}
+
+// evaluate returns the aggregate of the collected values, or nil when it is not
+// defined: numeric aggregates over non-numeric values and avg, min and max of no
+// values. Comparisons against nil are false.
+func (f aggregateField) evaluate(input map[string]interface{}) interface{} {
+	values := f.collect(input)
+	switch f.function {
+	case aggregatefunctions.Count:
+		return len(values)
+	case aggregatefunctions.DistinctCount:
+		seen := make(map[string]bool, len(values))
+		for _, value := range values {
+			seen[valueKey(value)] = true
+		}
+		return len(seen)
+	}
+
+	numbers := make([]decimal.Decimal, 0, len(values))
+	for _, value := range values {
+		number, ok := toDecimal(value)
+		if !ok {
+			return nil
+		}
+		numbers = append(numbers, number)
+	}
+	if f.function == aggregatefunctions.Sum {
+		sum := decimal.NewFromInt(0)
+		for _, number := range numbers {
+			sum = sum.Add(number)
+		}
+		return sum
+	}
+	if len(numbers) == 0 {
+		return nil
+	}
+	result := numbers[0]
+	for _, number := range numbers[1:] {
+		switch f.function {
+		case aggregatefunctions.Avg:
+			result = result.Add(number)
+		case aggregatefunctions.Min:
+			if number.Cmp(result) < 0 {
+				result = number
+			}
+		case aggregatefunctions.Max:
+			if number.Cmp(result) > 0 {
+				result = number
+			}
+		}
+	}
+	if f.function == aggregatefunctions.Avg {
+		avg, err := result.Div(decimal.NewFromInt(int64(len(numbers))), divisionScale, decimal.HalfEven)
+		if err != nil {
+			return nil
+		}
+		return avg
+	}
+	return result
+}
+
+// fieldValue resolves the left side of a comparison, which is either a field of
+// the input or an aggregate field expression.
+func (re *engine) fieldValue(input map[string]interface{}, name string) interface{} {
+	if !isAggregateField(name) {
+		return input[name]
+	}
+	field, err := re.config.aggregates.get(name)
+	if err != nil {
+		return nil
+	}
+	return field.evaluate(input)
+}
@@ -0,0 +1,82 @@
+package ruleengine
+
+import (
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"testing"
+)
+
+type lineItem map[string]interface{}
+
+func Test_ruleEngine_ApplyAggregate(t *testing.T) {
+	input := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"sku": "A1", "price": 150.5, "tags": []interface{}{"food", "promo"}},
+			map[string]interface{}{"sku": "B2", "price": "250"},
+			map[string]interface{}{"sku": "A1", "price": 50},
+		},
+		"orders": []map[string]interface{}{
+			{"lines": []lineItem{{"qty": 2}, {"qty": 3}}},
+			{"lines": []lineItem{{"qty": 5}}},
+		},
+		"scores": []float64{0.1, 0.2},
+		"names":  []interface{}{"bca", "x"},
+		"empty":  []interface{}{},
+	}
+	tests := []struct {
+		name      string
+		condition Condition
+		expected  bool
+	}{
+		{name: "Sum", condition: NewCondition("sum(items[*].price)", operators.Equals, 450.5), expected: true},
+		{name: "Sum greater than", condition: NewCondition("sum(items[*].price)", operators.GreaterThan, 1000), expected: false},
+		{name: "Avg", condition: NewCondition("avg(items[*].price)", operators.GreaterThan, 150), expected: true},
+		{name: "Min", condition: NewCondition("min(items[*].price)", operators.Equals, 50), expected: true},
+		{name: "Max", condition: NewCondition("max(items[*].price)", operators.Equals, 250), expected: true},
+		{name: "Count", condition: NewCondition("count(items[*])", operators.Equals, 3), expected: true},
+		{name: "Distinct count", condition: NewCondition("distinct_count(items[*].sku)", operators.Equals, 2), expected: true},
+		{name: "Nested arrays", condition: NewCondition("sum(orders[*].lines[*].qty)", operators.Equals, 10), expected: true},
+		{name: "Flatten arrays at end of path", condition: NewCondition("count(items[*].tags)", operators.Equals, 2), expected: true},
+		{name: "Go slice", condition: NewCondition("sum(scores)", operators.Equals, 0.3), expected: true},
+		{name: "Non-numeric values", condition: NewCondition("sum(names)", operators.GreaterThanEquals, 0), expected: false},
+		{name: "Sum of empty array", condition: NewCondition("sum(empty)", operators.Equals, 0), expected: true},
+		{name: "Max of empty array", condition: NewCondition("max(empty)", operators.LessThan, 0), expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ruleSet := RuleSet{Rules: []interface{}{Rule{ID: 1, Condition: NewGroupCondition(logicaloperators.And, tt.condition)}}}
+			output := NewRuleEngine().RegisterRuleSet(ruleSet).Apply(input).GetResult()
+			if output.Error != "" {
+				t.Fatalf("Error applying rule set: %v", output.Error)
+			}
+			if output.Valid != tt.expected {
+				t.Errorf("Unexpected output. Expected: %v, Got: %v", tt.expected, output.Valid)
+			}
+		})
+	}
+}
+
+func Test_parseAggregateField(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		valid bool
+	}{
+		{name: "Nested path", field: "sum(orders[*].lines[*].qty)", valid: true},
+		{name: "Unknown function", field: "median(items[*].price)"},
+		{name: "Empty segment", field: "sum(items[*]..price)"},
+		{name: "Invalid index", field: "sum(items[0].price)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseAggregateField(tt.field)
+			if (err == nil) != tt.valid {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			ruleSet := RuleSet{Rules: []interface{}{Rule{ID: 1, Condition: NewGroupCondition(logicaloperators.And, NewCondition(tt.field, operators.GreaterThan, 0))}}}
+			if err := NewRuleEngine().RegisterRuleSet(ruleSet).Err(); (err == nil) != tt.valid {
+				t.Errorf("Unexpected registration error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,574 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/decimal"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/finding-kind"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"reflect"
+)
+
+// maxConjuncts bounds the size of the disjunctive normal form built for a rule;
+// rules that expand beyond it are reported as too complex instead of analyzed.
+const maxConjuncts = 256
+
+type Finding struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path"`
+	RuleIDs []int  `json:"rule_ids"`
+	Message string `json:"message"`
+}
+
+type AnalysisReport struct {
+	Findings []Finding `json:"findings"`
+}
+
+type bound struct {
+	set       bool
+	value     decimal.Decimal
+	inclusive bool
+}
+
+// fieldConstraint is the conjunction of every predicate on one field: an optional
+// finite set of allowed values, excluded values, a numeric range and predicates
+// the analyzer cannot reason about, kept as opaque descriptions.
+type fieldConstraint struct {
+	restricted bool
+	allowed    []interface{}
+	excluded   []interface{}
+	lower      bound
+	upper      bound
+	opaque     []string
+}
+
+type conjunct map[string]*fieldConstraint
+
+type analyzedRule struct {
+	rule     Rule
+	dnf      []conjunct
+	analyzed bool
+}
+
+type analyzer struct {
+	re     *engine
+	report AnalysisReport
+	ids    map[int]string
+}
+
+func AnalyzeJsonRuleSet(ruleSetStr string, opts ...Option) (AnalysisReport, error) {
+	var ruleSet RuleSet
+	if err := json.Unmarshal([]byte(ruleSetStr), &ruleSet); err != nil {
+		return AnalysisReport{}, err
+	}
+	return AnalyzeRuleSet(ruleSet, opts...)
+}
+
+// AnalyzeRuleSet resolves fragment references with the registry given by
+// WithFragments before analyzing the rule set.
+func AnalyzeRuleSet(ruleSet RuleSet, opts ...Option) (AnalysisReport, error) {
+	a := &analyzer{
+		re:  NewRuleEngine(opts...).(*engine),
+		ids: make(map[int]string),
+	}
+	ruleSet, err := a.re.resolveRuleSet(ruleSet)
+	if err != nil {
+		return AnalysisReport{}, err
+	}
+	err = a.analyzeRuleSet(ruleSet, "$")
+	return a.report, err
+}
+
+func (r AnalysisReport) HasFindings() bool {
+	return len(r.Findings) > 0
+}
+
+func (a *analyzer) addFinding(kind, path, message string, ruleIDs ...int) {
+	a.report.Findings = append(a.report.Findings, Finding{
+		Kind:    kind,
+		Path:    path,
+		RuleIDs: ruleIDs,
+		Message: message,
+	})
+}
+
+func (a *analyzer) analyzeRuleSet(ruleSet RuleSet, path string) error {
+	if ruleSet.LogicalOperator == "" {
+		ruleSet.LogicalOperator = logicaloperators.And
+	}
+	var rules []analyzedRule
+	for i, nestedRule := range ruleSet.Rules {
+		var rule Rule
+		switch r := nestedRule.(type) {
+		case map[string]interface{}:
+			if _, ok := r["rules"]; ok {
+				nested, err := decodeMapRuleSet(r)
+				if err != nil {
+					return err
+				}
+				if err = a.analyzeRuleSet(nested, fmt.Sprintf("%s.rules[%d]", path, i)); err != nil {
+					return err
+				}
+				continue
+			}
+			decoded, err := decodeMapRule(r)
+			if err != nil {
+				return err
+			}
+			rule = decoded
+		case Rule:
+			rule = r
+		default:
+			return errors.New(fmt.Sprintf("invalid nested rule type: %s", reflect.TypeOf(nestedRule)))
+		}
+		rules = append(rules, a.analyzeRule(rule, path))
+	}
+	a.compareRules(ruleSet.LogicalOperator, rules, path)
+	return nil
+}
+
+func (a *analyzer) analyzeRule(rule Rule, path string) analyzedRule {
+	if previous, ok := a.ids[rule.ID]; ok {
+		a.addFinding(findingkinds.DuplicateID, path, fmt.Sprintf("rule id #%d is already used at %s", rule.ID, previous), rule.ID)
+	} else {
+		a.ids[rule.ID] = path
+	}
+
+	if rule.Condition.LogicalOperator == "" {
+		rule.Condition.LogicalOperator = logicaloperators.And
+	}
+	dnf, ok := a.toDNF(rule.Condition)
+	switch {
+	case !ok:
+		a.addFinding(findingkinds.TooComplex, path, fmt.Sprintf("rule id #%d expands to more than %d cases and was not analyzed", rule.ID, maxConjuncts), rule.ID)
+	case len(dnf) == 0:
+		a.addFinding(findingkinds.Unsatisfiable, path, fmt.Sprintf("rule id #%d can never be true", rule.ID), rule.ID)
+	case hasEmptyConjunct(dnf):
+		a.addFinding(findingkinds.Tautology, path, fmt.Sprintf("rule id #%d is always true", rule.ID), rule.ID)
+	}
+	return analyzedRule{rule: rule, dnf: dnf, analyzed: ok}
+}
+
+func (a *analyzer) compareRules(logicalOperator string, rules []analyzedRule, path string) {
+	for i := 0; i < len(rules); i++ {
+		if !rules[i].analyzed || len(rules[i].dnf) == 0 {
+			continue
+		}
+		for j := i + 1; j < len(rules); j++ {
+			if !rules[j].analyzed || len(rules[j].dnf) == 0 {
+				continue
+			}
+			first, second := rules[i].rule.ID, rules[j].rule.ID
+			iImpliesJ := a.implies(rules[i].dnf, rules[j].dnf)
+			jImpliesI := a.implies(rules[j].dnf, rules[i].dnf)
+			switch {
+			case iImpliesJ && jImpliesI:
+				a.addFinding(findingkinds.DuplicateRule, path, fmt.Sprintf("rule id #%d and rule id #%d have equivalent conditions", first, second), first, second)
+			case iImpliesJ:
+				a.addFinding(findingkinds.Subsumed, path, subsumedMessage(logicalOperator, first, second), first, second)
+			case jImpliesI:
+				a.addFinding(findingkinds.Subsumed, path, subsumedMessage(logicalOperator, second, first), second, first)
+			}
+		}
+	}
+
+	// Under first-match semantics a rule is unreachable when every input it matches
+	// is already matched by an earlier rule.
+	var earlier []conjunct
+	for _, rule := range rules {
+		if !rule.analyzed {
+			continue
+		}
+		if len(rule.dnf) > 0 && len(earlier) > 0 && a.implies(rule.dnf, earlier) {
+			a.addFinding(findingkinds.Unreachable, path, fmt.Sprintf("rule id #%d is unreachable under first-match semantics", rule.rule.ID), rule.rule.ID)
+		}
+		earlier = append(earlier, rule.dnf...)
+	}
+}
+
+// subsumedMessage describes narrow implying wide: in an OR rule set the narrow
+// rule never changes the outcome, in an AND rule set the wide rule never does.
+func subsumedMessage(logicalOperator string, narrow, wide int) string {
+	switch logicalOperator {
+	case logicaloperators.Or, logicaloperators.Nor:
+		return fmt.Sprintf("rule id #%d is subsumed by rule id #%d and never changes the result", narrow, wide)
+	case logicaloperators.Xor:
+		return fmt.Sprintf("rule id #%d implies rule id #%d", narrow, wide)
+	}
+	return fmt.Sprintf("rule id #%d implies rule id #%d, so rule id #%d never changes the result", narrow, wide, wide)
+}
+
+func hasEmptyConjunct(dnf []conjunct) bool {
+	for _, c := range dnf {
+		if len(c) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *analyzer) toDNF(condition Condition) ([]conjunct, bool) {
+	switch condition.LogicalOperator {
+	case logicaloperators.And:
+		result := []conjunct{{}}
+		for _, subCondition := range condition.Conditions {
+			child, ok := a.toDNF(subCondition)
+			if !ok {
+				return nil, false
+			}
+			var product []conjunct
+			for _, left := range result {
+				for _, right := range child {
+					merged := a.mergeConjuncts(left, right)
+					if !a.satisfiable(merged) {
+						continue
+					}
+					product = append(product, merged)
+					if len(product) > maxConjuncts {
+						return nil, false
+					}
+				}
+			}
+			result = product
+		}
+		return result, true
+	case logicaloperators.Or:
+		var result []conjunct
+		for _, subCondition := range condition.Conditions {
+			child, ok := a.toDNF(subCondition)
+			if !ok {
+				return nil, false
+			}
+			result = append(result, child...)
+			if len(result) > maxConjuncts {
+				return nil, false
+			}
+		}
+		return result, true
+	case logicaloperators.Not, logicaloperators.Nand, logicaloperators.Nor, logicaloperators.Xor:
+		if condition.LogicalOperator == logicaloperators.Not && len(condition.Conditions) == 1 && !isGroup(condition.Conditions[0]) {
+			predicate := condition.Conditions[0]
+			c := conjunct{constrainedField(predicate): &fieldConstraint{opaque: []string{"not " + opaqueDescription(predicate)}}}
+			if !a.satisfiable(c) {
+				return nil, true
+			}
+			return []conjunct{c}, true
+		}
+		if condition.LogicalOperator != logicaloperators.Xor {
+			// A negated group that can never be true is always true and vice versa.
+			inner := Condition{LogicalOperator: logicaloperators.And, Conditions: condition.Conditions}
+			if condition.LogicalOperator == logicaloperators.Nor {
+				inner.LogicalOperator = logicaloperators.Or
+			}
+			if dnf, ok := a.toDNF(inner); ok {
+				if len(dnf) == 0 {
+					return []conjunct{{}}, true
+				}
+				if hasEmptyConjunct(dnf) {
+					return nil, true
+				}
+			}
+		}
+		expanded, err := pushNegation(condition, false)
+		if err != nil {
+			return nil, false
+		}
+		return a.toDNF(expanded)
+	}
+
+	c := conjunct{constrainedField(condition): a.atomConstraint(condition)}
+	if !a.satisfiable(c) {
+		return nil, true
+	}
+	return []conjunct{c}, true
+}
+
+func (a *analyzer) atomConstraint(condition Condition) *fieldConstraint {
+	constraint := &fieldConstraint{}
+	if condition.Quantifier != "" {
+		constraint.opaque = []string{opaqueDescription(condition)}
+		return constraint
+	}
+	switch condition.Operator {
+	case operators.Equals:
+		constraint.restricted = true
+		constraint.allowed = []interface{}{condition.Value}
+		return constraint
+	case operators.In:
+		values := reflect.ValueOf(condition.Value)
+		if values.Kind() == reflect.Slice || values.Kind() == reflect.Array {
+			constraint.restricted = true
+			for i := 0; i < values.Len(); i++ {
+				constraint.allowed = append(constraint.allowed, values.Index(i).Interface())
+			}
+			return constraint
+		}
+	case operators.NotEquals:
+		constraint.excluded = []interface{}{condition.Value}
+		return constraint
+	case operators.GreaterThan, operators.GreaterThanEquals:
+		if value, ok := toDecimal(condition.Value); ok {
+			constraint.lower = bound{set: true, value: value, inclusive: condition.Operator == operators.GreaterThanEquals}
+			return constraint
+		}
+	case operators.LessThan, operators.LessThanEquals:
+		if value, ok := toDecimal(condition.Value); ok {
+			constraint.upper = bound{set: true, value: value, inclusive: condition.Operator == operators.LessThanEquals}
+			return constraint
+		}
+	}
+	constraint.opaque = []string{opaqueDescription(condition)}
+	return constraint
+}
+
+// constrainedField is the field a predicate constrains; window conditions
+// constrain the aggregate of their window.
+func constrainedField(condition Condition) string {
+	if condition.Window != nil {
+		return condition.Window.id()
+	}
+	return condition.Name
+}
+
+func opaqueDescription(condition Condition) string {
+	if condition.Quantifier != "" {
+		return conditionKey(condition)
+	}
+	return fmt.Sprintf("%s %v", condition.Operator, condition.Value)
+}
+
+func (a *analyzer) mergeConjuncts(left, right conjunct) conjunct {
+	merged := make(conjunct, len(left)+len(right))
+	for field, constraint := range left {
+		merged[field] = constraint
+	}
+	for field, constraint := range right {
+		if existing, ok := merged[field]; ok {
+			merged[field] = a.mergeConstraints(existing, constraint)
+		} else {
+			merged[field] = constraint
+		}
+	}
+	return merged
+}
+
+func (a *analyzer) mergeConstraints(left, right *fieldConstraint) *fieldConstraint {
+	merged := &fieldConstraint{
+		restricted: left.restricted || right.restricted,
+		excluded:   append(append([]interface{}(nil), left.excluded...), right.excluded...),
+		lower:      tighterBound(left.lower, right.lower, 1),
+		upper:      tighterBound(left.upper, right.upper, -1),
+		opaque:     append(append([]string(nil), left.opaque...), right.opaque...),
+	}
+	switch {
+	case left.restricted && right.restricted:
+		for _, value := range left.allowed {
+			if a.re.isIn(value, right.allowed) {
+				merged.allowed = append(merged.allowed, value)
+			}
+		}
+	case left.restricted:
+		merged.allowed = left.allowed
+	case right.restricted:
+		merged.allowed = right.allowed
+	}
+	return merged
+}
+
+// tighterBound keeps the larger lower bound (direction 1) or the smaller upper
+// bound (direction -1), preferring the exclusive bound when values are equal.
+func tighterBound(x, y bound, direction int) bound {
+	if !x.set {
+		return y
+	}
+	if !y.set {
+		return x
+	}
+	cmp := x.value.Cmp(y.value) * direction
+	if cmp > 0 || (cmp == 0 && !x.inclusive) {
+		return x
+	}
+	return y
+}
+
+func (a *analyzer) satisfiable(c conjunct) bool {
+	for _, constraint := range c {
+		for _, description := range constraint.opaque {
+			if containsString(constraint.opaque, "not "+description) {
+				return false
+			}
+		}
+		if constraint.restricted {
+			if len(a.effectiveAllowed(constraint)) == 0 {
+				return false
+			}
+			continue
+		}
+		if constraint.lower.set && constraint.upper.set {
+			cmp := constraint.lower.value.Cmp(constraint.upper.value)
+			if cmp > 0 {
+				return false
+			}
+			if cmp == 0 && (!constraint.lower.inclusive || !constraint.upper.inclusive || a.re.isIn(constraint.lower.value, constraint.excluded)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (a *analyzer) effectiveAllowed(constraint *fieldConstraint) []interface{} {
+	var allowed []interface{}
+	for _, value := range constraint.allowed {
+		if a.admits(constraint, value) {
+			allowed = append(allowed, value)
+		}
+	}
+	return allowed
+}
+
+func (a *analyzer) admits(constraint *fieldConstraint, value interface{}) bool {
+	if constraint.restricted && !a.re.isIn(value, constraint.allowed) {
+		return false
+	}
+	if a.re.isIn(value, constraint.excluded) {
+		return false
+	}
+	return !a.outsideRange(constraint, value)
+}
+
+func (a *analyzer) outsideRange(constraint *fieldConstraint, value interface{}) bool {
+	if !constraint.lower.set && !constraint.upper.set {
+		return false
+	}
+	d, ok := toDecimal(value)
+	if !ok {
+		return true
+	}
+	if constraint.lower.set {
+		cmp := d.Cmp(constraint.lower.value)
+		if cmp < 0 || (cmp == 0 && !constraint.lower.inclusive) {
+			return true
+		}
+	}
+	if constraint.upper.set {
+		cmp := d.Cmp(constraint.upper.value)
+		if cmp > 0 || (cmp == 0 && !constraint.upper.inclusive) {
+			return true
+		}
+	}
+	return false
+}
+
+// implies reports whether every case of narrow is contained in some case of wide.
+// It is sound but incomplete: false means the analyzer could not prove it.
+func (a *analyzer) implies(narrow, wide []conjunct) bool {
+	for _, n := range a.splitConjuncts(narrow) {
+		implied := false
+		for _, w := range wide {
+			if a.conjunctImplies(n, w) {
+				implied = true
+				break
+			}
+		}
+		if !implied {
+			return false
+		}
+	}
+	return true
+}
+
+// splitConjuncts expands fields restricted to several values into one case per
+// value, so `in [a, b]` can be shown to be covered by `equals a` and `equals b`.
+func (a *analyzer) splitConjuncts(dnf []conjunct) []conjunct {
+	var result []conjunct
+	for _, c := range dnf {
+		cases := []conjunct{c}
+		for field, constraint := range c {
+			allowed := a.effectiveAllowed(constraint)
+			if !constraint.restricted || len(allowed) < 2 || len(cases)*len(allowed) > maxConjuncts {
+				continue
+			}
+			var expanded []conjunct
+			for _, existing := range cases {
+				for _, value := range allowed {
+					single := *constraint
+					single.allowed = []interface{}{value}
+					copied := make(conjunct, len(existing))
+					for name, fieldConstraint := range existing {
+						copied[name] = fieldConstraint
+					}
+					copied[field] = &single
+					expanded = append(expanded, copied)
+				}
+			}
+			cases = expanded
+		}
+		result = append(result, cases...)
+	}
+	return result
+}
+
+func (a *analyzer) conjunctImplies(narrow, wide conjunct) bool {
+	for field, w := range wide {
+		n, ok := narrow[field]
+		if !ok || !a.constraintImplies(n, w) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *analyzer) constraintImplies(narrow, wide *fieldConstraint) bool {
+	for _, description := range wide.opaque {
+		if !containsString(narrow.opaque, description) {
+			return false
+		}
+	}
+	if narrow.restricted {
+		for _, value := range a.effectiveAllowed(narrow) {
+			if !a.admits(wide, value) {
+				return false
+			}
+		}
+		return true
+	}
+	if wide.restricted {
+		return false
+	}
+	for _, value := range wide.excluded {
+		if !a.re.isIn(value, narrow.excluded) && !a.outsideRange(narrow, value) {
+			return false
+		}
+	}
+	if wide.lower.set {
+		if !narrow.lower.set {
+			return false
+		}
+		cmp := narrow.lower.value.Cmp(wide.lower.value)
+		if cmp < 0 || (cmp == 0 && narrow.lower.inclusive && !wide.lower.inclusive) {
+			return false
+		}
+	}
+	if wide.upper.set {
+		if !narrow.upper.set {
+			return false
+		}
+		cmp := narrow.upper.value.Cmp(wide.upper.value)
+		if cmp > 0 || (cmp == 0 && narrow.upper.inclusive && !wide.upper.inclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
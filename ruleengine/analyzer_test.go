@@ -0,0 +1,99 @@
+package ruleengine
+
+import (
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/finding-kind"
+	"reflect"
+	"testing"
+)
+
+func Test_AnalyzeJsonRuleSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		ruleSet  string
+		expected []Finding
+	}{
+		{
+			name:    "Contradictory range",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":1000},{"name":"amount","operator":"less_than","value":500}]}}]}`,
+			expected: []Finding{
+				{Kind: findingkinds.Unsatisfiable, Path: "$", RuleIDs: []int{1}, Message: "rule id #1 can never be true"},
+			},
+		},
+		{
+			name:    "Contradictory equality",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"bank_id","operator":"equals","value":"bca"},{"name":"bank_id","operator":"in","value":["bni","bri"]}]}}]}`,
+			expected: []Finding{
+				{Kind: findingkinds.Unsatisfiable, Path: "$", RuleIDs: []int{1}, Message: "rule id #1 can never be true"},
+			},
+		},
+		{
+			name:    "Negated predicate contradicts predicate",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"remark","operator":"match","value":"^BFST"},{"logical_operator":"NOT","conditions":[{"name":"remark","operator":"match","value":"^BFST"}]}]}}]}`,
+			expected: []Finding{
+				{Kind: findingkinds.Unsatisfiable, Path: "$", RuleIDs: []int{1}, Message: "rule id #1 can never be true"},
+			},
+		},
+		{
+			name:    "Negated contradiction",
+			ruleSet: `{"rules":[{"id":1,"condition":{"logical_operator":"NAND","conditions":[{"name":"amount","operator":"greater_than","value":1000},{"name":"amount","operator":"less_than","value":500}]}}]}`,
+			expected: []Finding{
+				{Kind: findingkinds.Tautology, Path: "$", RuleIDs: []int{1}, Message: "rule id #1 is always true"},
+			},
+		},
+		{
+			name:    "Tautology",
+			ruleSet: `{"rules":[{"id":1,"condition":{}}]}`,
+			expected: []Finding{
+				{Kind: findingkinds.Tautology, Path: "$", RuleIDs: []int{1}, Message: "rule id #1 is always true"},
+			},
+		},
+		{
+			name:    "Duplicate rules with different ids",
+			ruleSet: `{"logical_operator":"OR","rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":1000},{"name":"remark","operator":"match","value":"^BFST"}]}},{"id":2,"condition":{"conditions":[{"name":"remark","operator":"match","value":"^BFST"},{"name":"amount","operator":"greater_than","value":1000.0}]}}]}`,
+			expected: []Finding{
+				{Kind: findingkinds.DuplicateRule, Path: "$", RuleIDs: []int{1, 2}, Message: "rule id #1 and rule id #2 have equivalent conditions"},
+				{Kind: findingkinds.Unreachable, Path: "$", RuleIDs: []int{2}, Message: "rule id #2 is unreachable under first-match semantics"},
+			},
+		},
+		{
+			name:    "Subsumed rule and duplicate id",
+			ruleSet: `{"logical_operator":"OR","rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":1000}]}},{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than_equals","value":5000},{"name":"bank_id","operator":"equals","value":"bca"}]}}]}`,
+			expected: []Finding{
+				{Kind: findingkinds.DuplicateID, Path: "$", RuleIDs: []int{1}, Message: "rule id #1 is already used at $"},
+				{Kind: findingkinds.Subsumed, Path: "$", RuleIDs: []int{1, 1}, Message: "rule id #1 is subsumed by rule id #1 and never changes the result"},
+				{Kind: findingkinds.Unreachable, Path: "$", RuleIDs: []int{1}, Message: "rule id #1 is unreachable under first-match semantics"},
+			},
+		},
+		{
+			name:    "Unreachable by union of earlier rules",
+			ruleSet: `{"logical_operator":"OR","rules":[{"id":1,"condition":{"conditions":[{"name":"bank_id","operator":"equals","value":"bca"}]}},{"id":2,"condition":{"conditions":[{"name":"bank_id","operator":"equals","value":"bni"}]}},{"id":3,"condition":{"conditions":[{"name":"bank_id","operator":"in","value":["bca","bni"]}]}}]}`,
+			expected: []Finding{
+				{Kind: findingkinds.Subsumed, Path: "$", RuleIDs: []int{1, 3}, Message: "rule id #1 is subsumed by rule id #3 and never changes the result"},
+				{Kind: findingkinds.Subsumed, Path: "$", RuleIDs: []int{2, 3}, Message: "rule id #2 is subsumed by rule id #3 and never changes the result"},
+				{Kind: findingkinds.Unreachable, Path: "$", RuleIDs: []int{3}, Message: "rule id #3 is unreachable under first-match semantics"},
+			},
+		},
+		{
+			name:    "Nested rule set",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":1}]}},{"rules":[{"id":2,"condition":{"logical_operator":"OR","conditions":[{"name":"amount","operator":"less_than","value":1},{"name":"amount","operator":"greater_than","value":1}]}},{"id":3,"condition":{"conditions":[{"name":"amount","operator":"not_equals","value":5},{"name":"amount","operator":"equals","value":5}]}}]}]}`,
+			expected: []Finding{
+				{Kind: findingkinds.Unsatisfiable, Path: "$.rules[1]", RuleIDs: []int{3}, Message: "rule id #3 can never be true"},
+			},
+		},
+		{
+			name:    "Clean rule set",
+			ruleSet: `{"logical_operator":"OR","rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":1000}]}},{"id":2,"condition":{"conditions":[{"name":"amount","operator":"less_than","value":10}]}}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := AnalyzeJsonRuleSet(tt.ruleSet)
+			if err != nil {
+				t.Fatalf("Error analyzing rule set: %v", err)
+			}
+			if !reflect.DeepEqual(report.Findings, tt.expected) {
+				t.Errorf("Unexpected findings.\nExpected: %+v\nGot:      %+v", tt.expected, report.Findings)
+			}
+		})
+	}
+}
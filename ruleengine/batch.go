@@ -0,0 +1,228 @@
+package ruleengine
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+type BatchOptions struct {
+	Workers    int
+	BufferSize int
+}
+
+type BatchResult struct {
+	Results    []EngineResult  `json:"results"`
+	Statistics BatchStatistics `json:"statistics"`
+}
+
+type BatchItem struct {
+	Index  int          `json:"index"`
+	Result EngineResult `json:"result"`
+}
+
+type BatchStatistics struct {
+	Total     int                       `json:"total"`
+	Valid     int                       `json:"valid"`
+	Errors    int                       `json:"errors"`
+	MatchRate float64                   `json:"match_rate"`
+	Rules     map[string]RuleStatistics `json:"rules"`
+}
+
+type RuleStatistics struct {
+	Evaluated int     `json:"evaluated"`
+	Matched   int     `json:"matched"`
+	MatchRate float64 `json:"match_rate"`
+}
+
+type batchJob struct {
+	index int
+	input map[string]interface{}
+}
+
+type batchOutcome struct {
+	index       int
+	result      EngineResult
+	ruleResults map[string]bool
+}
+
+func (opts BatchOptions) workers() int {
+	if opts.Workers > 0 {
+		return opts.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+func (p *processor) ApplyBatch(ctx context.Context, inputs []map[string]interface{}, opts BatchOptions) (BatchResult, error) {
+	if p.err != nil {
+		return BatchResult{}, p.err
+	}
+	jobs := make(chan batchJob)
+	outcomes := make(chan batchOutcome)
+	go func() {
+		defer close(jobs)
+		for i, input := range inputs {
+			select {
+			case jobs <- batchJob{index: i, input: input}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	p.startWorkers(ctx, jobs, outcomes, opts.workers())
+
+	batchResult := BatchResult{
+		Results: make([]EngineResult, len(inputs)),
+		Statistics: BatchStatistics{
+			Rules: make(map[string]RuleStatistics),
+		},
+	}
+	for outcome := range outcomes {
+		batchResult.Results[outcome.index] = outcome.result
+		batchResult.Statistics.add(outcome)
+	}
+	batchResult.Statistics.computeRates()
+	return batchResult, ctx.Err()
+}
+
+func (p *processor) ApplyStream(ctx context.Context, inputs <-chan map[string]interface{}, opts BatchOptions) <-chan BatchItem {
+	items := make(chan BatchItem, opts.BufferSize)
+	if p.err != nil {
+		go func() {
+			defer close(items)
+			index := 0
+			for range inputs {
+				select {
+				case items <- BatchItem{Index: index, Result: EngineResult{Error: p.err.Error()}}:
+					index++
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return items
+	}
+	jobs := make(chan batchJob)
+	outcomes := make(chan batchOutcome)
+	// An input is only dispatched while fewer than workers+BufferSize inputs wait
+	// to be emitted, so a slow input holds back the stream instead of letting the
+	// outcomes after it pile up.
+	slots := make(chan struct{}, opts.workers()+opts.BufferSize)
+	go func() {
+		defer close(jobs)
+		index := 0
+		for {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case input, ok := <-inputs:
+				if !ok {
+					return
+				}
+				select {
+				case jobs <- batchJob{index: index, input: input}:
+					index++
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	p.startWorkers(ctx, jobs, outcomes, opts.workers())
+
+	// Workers finish out of order, so outcomes are held back until every earlier
+	// index has been emitted.
+	go func() {
+		defer close(items)
+		pending := make(map[int]EngineResult)
+		next := 0
+		for outcome := range outcomes {
+			pending[outcome.index] = outcome.result
+			for {
+				result, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				select {
+				case items <- BatchItem{Index: next, Result: result}:
+				case <-ctx.Done():
+					for range outcomes {
+					}
+					return
+				}
+				<-slots
+				next++
+			}
+		}
+	}()
+	return items
+}
+
+func (p *processor) startWorkers(ctx context.Context, jobs <-chan batchJob, outcomes chan<- batchOutcome, workers int) {
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ruleResults := make(map[string]bool)
+			worker := p.ruleEngine.fork()
+			worker.ruleObserver = func(id string, result interface{}) {
+				matched, _ := result.(bool)
+				ruleResults[id] = matched
+			}
+			for job := range jobs {
+				result, err := worker.evaluate(job.input, *worker.ruleSet)
+				if err != nil {
+					result.Error = err.Error()
+				}
+				outcome := batchOutcome{index: job.index, result: result, ruleResults: ruleResults}
+				ruleResults = make(map[string]bool)
+				select {
+				case outcomes <- outcome:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+}
+
+func (s *BatchStatistics) add(outcome batchOutcome) {
+	s.Total++
+	if outcome.result.Valid {
+		s.Valid++
+	}
+	if outcome.result.Error != "" {
+		s.Errors++
+	}
+	for id, matched := range outcome.ruleResults {
+		ruleStatistics := s.Rules[id]
+		ruleStatistics.Evaluated++
+		if matched {
+			ruleStatistics.Matched++
+		}
+		s.Rules[id] = ruleStatistics
+	}
+}
+
+func (s *BatchStatistics) computeRates() {
+	if s.Total > 0 {
+		s.MatchRate = float64(s.Valid) / float64(s.Total)
+	}
+	for id, ruleStatistics := range s.Rules {
+		if ruleStatistics.Evaluated > 0 {
+			ruleStatistics.MatchRate = float64(ruleStatistics.Matched) / float64(ruleStatistics.Evaluated)
+		}
+		s.Rules[id] = ruleStatistics
+	}
+}
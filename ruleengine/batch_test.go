@@ -0,0 +1,160 @@
+package ruleengine
+
+import (
+	"context"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/aggregate-function"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const batchRuleSet = `{"logical_operator":"OR","rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":2000}]}},{"id":2,"condition":{"conditions":[{"name":"remark","operator":"equals","value":"manual"}]}}]}`
+
+func batchInputs(n int) []map[string]interface{} {
+	inputs := make([]map[string]interface{}, n)
+	for i := range inputs {
+		inputs[i] = map[string]interface{}{
+			"amount": i * 100,
+			"remark": "auto",
+		}
+	}
+	return inputs
+}
+
+func Test_processor_ApplyBatch(t *testing.T) {
+	inputs := batchInputs(50)
+	output, err := NewRuleEngine().RegisterJsonRuleSet(batchRuleSet).ApplyBatch(context.Background(), inputs, BatchOptions{Workers: 4})
+	if err != nil {
+		t.Fatalf("Error applying batch: %v", err)
+	}
+	if len(output.Results) != len(inputs) {
+		t.Fatalf("Unexpected result count. Expected: %d, Got: %d", len(inputs), len(output.Results))
+	}
+	for i, result := range output.Results {
+		expected := i*100 > 2000
+		if result.Valid != expected {
+			t.Errorf("Unexpected result at %d. Expected: %v, Got: %v", i, expected, result.Valid)
+		}
+	}
+	if output.Statistics.Total != 50 || output.Statistics.Valid != 29 {
+		t.Errorf("Unexpected statistics: %+v", output.Statistics)
+	}
+	if rule := output.Statistics.Rules["1"]; rule.Evaluated != 50 || rule.Matched != 29 {
+		t.Errorf("Unexpected rule statistics: %+v", rule)
+	}
+	if rule := output.Statistics.Rules["2"]; rule.Evaluated != 50 || rule.Matched != 0 || rule.MatchRate != 0 {
+		t.Errorf("Unexpected rule statistics: %+v", rule)
+	}
+}
+
+func Test_processor_ApplyStream(t *testing.T) {
+	inputs := make(chan map[string]interface{})
+	go func() {
+		defer close(inputs)
+		for _, input := range batchInputs(30) {
+			inputs <- input
+		}
+	}()
+	items := NewRuleEngine().RegisterJsonRuleSet(batchRuleSet).ApplyStream(context.Background(), inputs, BatchOptions{Workers: 3})
+	next := 0
+	for item := range items {
+		if item.Index != next {
+			t.Fatalf("Unexpected item order. Expected: %d, Got: %d", next, item.Index)
+		}
+		if item.Result.Valid != (next*100 > 2000) {
+			t.Errorf("Unexpected result at %d: %v", next, item.Result.Valid)
+		}
+		next++
+	}
+	if next != 30 {
+		t.Errorf("Unexpected item count. Expected: 30, Got: %d", next)
+	}
+}
+
+func Test_processor_ApplyStream_RegistrationError(t *testing.T) {
+	inputs := make(chan map[string]interface{})
+	go func() {
+		defer close(inputs)
+		for _, input := range batchInputs(5) {
+			inputs <- input
+		}
+	}()
+	items := NewRuleEngine().RegisterJsonRuleSet(`{"rules":[{"id":1,"condition":{"logical_operator":"IMPLIES"}}]}`).ApplyStream(context.Background(), inputs, BatchOptions{})
+	next := 0
+	for item := range items {
+		if item.Index != next {
+			t.Fatalf("Unexpected item index. Expected: %d, Got: %d", next, item.Index)
+		}
+		if item.Result.Error == "" {
+			t.Errorf("Expected a registration error at %d", next)
+		}
+		next++
+	}
+	if next != 5 {
+		t.Errorf("Unexpected item count. Expected: 5, Got: %d", next)
+	}
+}
+
+func Test_processor_ApplyBatch_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewRuleEngine().RegisterJsonRuleSet(batchRuleSet).ApplyBatch(ctx, batchInputs(10), BatchOptions{})
+	if err != context.Canceled {
+		t.Errorf("Unexpected error. Expected: %v, Got: %v", context.Canceled, err)
+	}
+}
+
+// blockingStateStore holds up the evaluation of the "slow" account until release
+// is closed.
+type blockingStateStore struct {
+	*MemoryStateStore
+	release chan struct{}
+}
+
+func (s *blockingStateStore) Append(key string, event WindowEvent, now time.Time) ([]WindowEvent, error) {
+	if strings.Contains(key, "slow") {
+		<-s.release
+	}
+	return s.MemoryStateStore.Append(key, event, now)
+}
+
+func Test_processor_ApplyStream_Backpressure(t *testing.T) {
+	store := &blockingStateStore{MemoryStateStore: NewMemoryStateStore(), release: make(chan struct{})}
+	ruleSet := windowRuleSet(Window{Function: aggregatefunctions.Count, Key: "account", Duration: "1h"}, operators.GreaterThan, 0)
+	processor := NewRuleEngine(WithStateStore(store)).RegisterRuleSet(ruleSet)
+	if err := processor.Err(); err != nil {
+		t.Fatalf("Error registering rule set: %v", err)
+	}
+
+	var sent int32
+	inputs := make(chan map[string]interface{})
+	go func() {
+		defer close(inputs)
+		inputs <- map[string]interface{}{"account": "slow"}
+		atomic.AddInt32(&sent, 1)
+		for i := 1; i < 20; i++ {
+			inputs <- map[string]interface{}{"account": "fast"}
+			atomic.AddInt32(&sent, 1)
+		}
+	}()
+	opts := BatchOptions{Workers: 2, BufferSize: 1}
+	items := processor.ApplyStream(context.Background(), inputs, opts)
+
+	time.Sleep(50 * time.Millisecond)
+	if got, limit := atomic.LoadInt32(&sent), int32(opts.Workers+opts.BufferSize); got > limit {
+		t.Errorf("Expected at most %d inputs in flight behind a slow one, got %d", limit, got)
+	}
+	close(store.release)
+	next := 0
+	for item := range items {
+		if item.Index != next || !item.Result.Valid {
+			t.Fatalf("Unexpected item %d: %+v", next, item)
+		}
+		next++
+	}
+	if next != 20 {
+		t.Errorf("Unexpected item count. Expected: 20, Got: %d", next)
+	}
+}
@@ -0,0 +1,97 @@
+package ruleengine
+
+import (
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/decimal"
+	"reflect"
+)
+
+type NumericPrecision struct {
+	Scale    int32
+	Rounding decimal.RoundingMode
+}
+
+// compare orders a and b when both normalize to decimals, rounding them to the
+// engine's precision first when one is configured.
+func (re *engine) compare(a, b interface{}) (int, bool) {
+	x, ok := re.toComparableDecimal(a)
+	if !ok {
+		return 0, false
+	}
+	y, ok := re.toComparableDecimal(b)
+	if !ok {
+		return 0, false
+	}
+	return x.Cmp(y), true
+}
+
+func (re *engine) toComparableDecimal(value interface{}) (decimal.Decimal, bool) {
+	d, ok := toDecimal(value)
+	if !ok {
+		return decimal.Decimal{}, false
+	}
+	if precision := re.config.numericPrecision; precision != nil {
+		d = d.Round(precision.Scale, precision.Rounding)
+	}
+	return d, true
+}
+
+func (re *engine) isEqual(a, b interface{}) bool {
+	if x, ok := toText(a); ok {
+		if y, ok := toText(b); ok {
+			return x == y
+		}
+	}
+	if x, ok := a.(bool); ok {
+		y, ok := b.(bool)
+		return ok && x == y
+	}
+	result, ok := re.compare(a, b)
+	return ok && result == 0
+}
+
+func (re *engine) isNotEqual(a, b interface{}) bool {
+	if x, ok := toText(a); ok {
+		if y, ok := toText(b); ok {
+			return x != y
+		}
+	}
+	if x, ok := a.(bool); ok {
+		y, ok := b.(bool)
+		return ok && x != y
+	}
+	result, ok := re.compare(a, b)
+	return ok && result != 0
+}
+
+func (re *engine) isGreaterThan(a, b interface{}) bool {
+	result, ok := re.compare(a, b)
+	return ok && result > 0
+}
+
+func (re *engine) isGreaterThanOrEqual(a, b interface{}) bool {
+	result, ok := re.compare(a, b)
+	return ok && result >= 0
+}
+
+func (re *engine) isLessThan(a, b interface{}) bool {
+	result, ok := re.compare(a, b)
+	return ok && result < 0
+}
+
+func (re *engine) isLessThanOrEqual(a, b interface{}) bool {
+	result, ok := re.compare(a, b)
+	return ok && result <= 0
+}
+
+func (re *engine) isIn(a, b interface{}) bool {
+	values := reflect.ValueOf(b)
+	if values.Kind() != reflect.Slice && values.Kind() != reflect.Array {
+		return false
+	}
+	for i := 0; i < values.Len(); i++ {
+		if re.isEqual(a, values.Index(i).Interface()) {
+			return true
+		}
+	}
+	return false
+}
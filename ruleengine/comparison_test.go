@@ -0,0 +1,122 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/decimal"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"math/big"
+	"testing"
+)
+
+func Test_ruleEngine_DecimalComparisons(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		input    interface{}
+		operator string
+		value    interface{}
+		expected bool
+	}{
+		{name: "Int not greater than fractional float", input: 100, operator: operators.GreaterThan, value: 100.5, expected: false},
+		{name: "Int less than fractional float", input: 100, operator: operators.LessThan, value: 100.5, expected: true},
+		{name: "Int not equal to fractional float", input: 100, operator: operators.Equals, value: 100.5, expected: false},
+		{name: "Float equal to int", input: 5000.0, operator: operators.Equals, value: 5000, expected: true},
+		{name: "Float compares exactly", input: 0.30000000000000004, operator: operators.Equals, value: 0.3, expected: false},
+		{name: "Json number", input: json.Number("100.50"), operator: operators.Equals, value: 100.5, expected: true},
+		{name: "Numeric string", input: "150000.25", operator: operators.GreaterThan, value: 150000, expected: true},
+		{name: "Numeric string equals number", input: "5000", operator: operators.Equals, value: 5000, expected: true},
+		{name: "Strings compare as text", input: "05000", operator: operators.Equals, value: "5000", expected: false},
+		{name: "Non numeric string", input: "abc", operator: operators.GreaterThan, value: 1, expected: false},
+		{name: "Exponent out of range", input: "1e50000000", operator: operators.GreaterThan, value: 100, expected: false},
+		{name: "Negative exponent out of range", input: "1e-50000000", operator: operators.LessThan, value: 100, expected: false},
+		{name: "Missing value", input: nil, operator: operators.NotEquals, value: 1, expected: false},
+		{name: "Decimal value", input: decimal.RequireFromString("10.01"), operator: operators.GreaterThanEquals, value: "10.01", expected: true},
+		{
+			name:     "Rounded to precision",
+			opts:     []Option{WithNumericPrecision(2, decimal.HalfUp)},
+			input:    100.004,
+			operator: operators.Equals,
+			value:    100,
+			expected: true,
+		},
+		{
+			name:     "Rounded half even",
+			opts:     []Option{WithNumericPrecision(0, decimal.HalfEven)},
+			input:    2.5,
+			operator: operators.LessThan,
+			value:    3,
+			expected: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re := NewRuleEngine(tt.opts...).(*engine)
+			output := re.evaluateConditions(map[string]interface{}{"amount": tt.input}, NewCondition("amount", tt.operator, tt.value))
+			if output != tt.expected {
+				t.Errorf("Unexpected output. Expected: %v, Got: %v", tt.expected, output)
+			}
+		})
+	}
+}
+
+type amount int64
+
+type currency string
+
+type rate float32
+
+func Test_ruleEngine_NumericTypeMatrix(t *testing.T) {
+	values := []interface{}{
+		int(100), int8(100), int16(100), int32(100), int64(100),
+		uint(100), uint8(100), uint16(100), uint32(100), uint64(100),
+		float32(100), float64(100), json.Number("100"), "100", "100.00",
+		big.NewInt(100), big.NewFloat(100), decimal.NewFromInt(100),
+		amount(100), rate(100),
+	}
+	for _, a := range values {
+		for _, b := range values {
+			re := NewRuleEngine().(*engine)
+			_, aIsText := toText(a)
+			_, bIsText := toText(b)
+			if aIsText && bIsText {
+				continue
+			}
+			name := fmt.Sprintf("%T(%v) vs %T(%v)", a, a, b, b)
+			if !re.isEqual(a, b) {
+				t.Errorf("%s: expected equal", name)
+			}
+			if re.isNotEqual(a, b) || re.isGreaterThan(a, b) || re.isLessThan(a, b) {
+				t.Errorf("%s: expected no strict ordering", name)
+			}
+			if !re.isGreaterThanOrEqual(a, b) || !re.isLessThanOrEqual(a, b) {
+				t.Errorf("%s: expected inclusive ordering", name)
+			}
+		}
+	}
+
+	larger := []interface{}{
+		int64(101), uint64(101), float32(100.5), 100.25, json.Number("100.01"), "100.001",
+		new(big.Int).Lsh(big.NewInt(1), 70), big.NewFloat(1e30), amount(1000),
+	}
+	for _, a := range values {
+		for _, b := range larger {
+			re := NewRuleEngine().(*engine)
+			if !re.isLessThan(a, b) || !re.isGreaterThan(b, a) {
+				t.Errorf("%T(%v) vs %T(%v): expected %v < %v", a, a, b, b, a, b)
+			}
+		}
+	}
+
+	re := NewRuleEngine().(*engine)
+	if !re.isEqual(currency("IDR"), "IDR") || re.isEqual(currency("IDR"), "USD") {
+		t.Errorf("Expected named string types to compare as text")
+	}
+	if re.isEqual(true, 1) || !re.isEqual(true, true) {
+		t.Errorf("Expected booleans to compare only with booleans")
+	}
+	var nilInt *big.Int
+	if re.isEqual(nilInt, 0) {
+		t.Errorf("Expected nil big.Int to be incomparable")
+	}
+}
@@ -1,11 +1,20 @@
 package ruleengine
 
+import (
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/quantifier"
+)
+
 type Condition struct {
 	LogicalOperator string      `json:"logical_operator,omitempty"`
 	Conditions      []Condition `json:"conditions,omitempty"`
 	Name            string      `json:"name,omitempty"`
 	Operator        string      `json:"operator,omitempty"`
 	Value           interface{} `json:"value,omitempty"`
+	Quantifier      string      `json:"quantifier,omitempty"`
+	Element         *Condition  `json:"element,omitempty"`
+	Window          *Window     `json:"window,omitempty"`
+	Ref             string      `json:"$ref,omitempty"`
 }
 
 func NewCondition(name string, operator string, value interface{}) Condition {
@@ -15,3 +24,25 @@ func NewCondition(name string, operator string, value interface{}) Condition {
 func NewGroupCondition(logicalOperator string, conditions ...Condition) Condition {
 	return Condition{LogicalOperator: logicalOperator, Conditions: conditions}
 }
+
+func NewNotCondition(condition Condition) Condition {
+	return Condition{LogicalOperator: logicaloperators.Not, Conditions: []Condition{condition}}
+}
+
+func NewQuantifiedCondition(name string, quantifier string, element Condition) Condition {
+	return Condition{Name: name, Quantifier: quantifier, Element: &element}
+}
+
+func NewCountCondition(name string, element Condition, operator string, value interface{}) Condition {
+	return Condition{Name: name, Quantifier: quantifiers.Count, Element: &element, Operator: operator, Value: value}
+}
+
+// implicitAnd defaults the logical operator of a group without one to AND, as
+// for the condition of a rule, so an empty condition always holds.
+func implicitAnd(condition Condition) Condition {
+	isEmpty := condition.Operator == "" && condition.Quantifier == "" && condition.Window == nil
+	if condition.LogicalOperator == "" && (len(condition.Conditions) > 0 || isEmpty) {
+		condition.LogicalOperator = logicaloperators.And
+	}
+	return condition
+}
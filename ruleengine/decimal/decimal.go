@@ -0,0 +1,317 @@
+package decimal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+type RoundingMode int
+
+const (
+	HalfUp RoundingMode = iota
+	HalfEven
+	HalfDown
+	Up
+	Down
+	Ceiling
+	Floor
+)
+
+// MaxScale bounds the scale of parsed numbers in both directions. Aligning two
+// decimals multiplies by 10^(scale difference), so an exponent such as 1e50000000
+// would otherwise cost seconds of CPU in a single comparison.
+const MaxScale = 10000
+
+var ErrDivisionByZero = errors.New("decimal division by zero")
+
+var (
+	bigZero = big.NewInt(0)
+	bigOne  = big.NewInt(1)
+	bigTen  = big.NewInt(10)
+)
+
+// Decimal is an exact base-10 number stored as value * 10^-scale.
+type Decimal struct {
+	value *big.Int
+	scale int32
+}
+
+var Zero = New(0, 0)
+
+func New(value int64, scale int32) Decimal {
+	return Decimal{value: big.NewInt(value), scale: scale}
+}
+
+func NewFromInt(value int64) Decimal {
+	return New(value, 0)
+}
+
+func NewFromUint(value uint64) Decimal {
+	return Decimal{value: new(big.Int).SetUint64(value)}
+}
+
+func NewFromBigInt(value *big.Int, scale int32) Decimal {
+	return Decimal{value: new(big.Int).Set(value), scale: scale}
+}
+
+// NewFromFloat uses the shortest decimal representation that round-trips to f,
+// so 0.1 becomes exactly 0.1 rather than its binary approximation.
+func NewFromFloat(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("cannot convert %v to decimal", f)
+	}
+	return NewFromString(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+func NewFromFloat32(f float32) (Decimal, error) {
+	if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+		return Decimal{}, fmt.Errorf("cannot convert %v to decimal", f)
+	}
+	return NewFromString(strconv.FormatFloat(float64(f), 'g', -1, 32))
+}
+
+func NewFromBigFloat(f *big.Float) (Decimal, error) {
+	if f.IsInf() {
+		return Decimal{}, fmt.Errorf("cannot convert %v to decimal", f)
+	}
+	return NewFromString(f.Text('g', -1))
+}
+
+func NewFromString(s string) (Decimal, error) {
+	original := s
+	s = strings.TrimSpace(s)
+	exponent := int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		exp, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", original)
+		}
+		exponent = exp
+		s = s[:i]
+	}
+	scale := int64(0)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		scale = int64(len(s) - i - 1)
+		s = s[:i] + s[i+1:]
+	}
+	if s == "" || s == "-" || s == "+" || strings.ContainsAny(s[1:], "+-") {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", original)
+	}
+	value, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", original)
+	}
+	scale -= exponent
+	if scale > MaxScale || scale < -MaxScale {
+		return Decimal{}, fmt.Errorf("decimal %q out of range: scale must be within ±%d", original, MaxScale)
+	}
+	return Decimal{value: value, scale: int32(scale)}, nil
+}
+
+func RequireFromString(s string) Decimal {
+	d, err := NewFromString(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) unscaled() *big.Int {
+	if d.value == nil {
+		return bigZero
+	}
+	return d.value
+}
+
+func (d Decimal) rescale(scale int32) *big.Int {
+	value := d.unscaled()
+	if scale <= d.scale {
+		return value
+	}
+	factor := new(big.Int).Exp(bigTen, big.NewInt(int64(scale-d.scale)), nil)
+	return new(big.Int).Mul(value, factor)
+}
+
+func align(a, b Decimal) (*big.Int, *big.Int, int32) {
+	scale := a.scale
+	if b.scale > scale {
+		scale = b.scale
+	}
+	return a.rescale(scale), b.rescale(scale), scale
+}
+
+func (d Decimal) Add(e Decimal) Decimal {
+	a, b, scale := align(d, e)
+	return Decimal{value: new(big.Int).Add(a, b), scale: scale}
+}
+
+func (d Decimal) Sub(e Decimal) Decimal {
+	a, b, scale := align(d, e)
+	return Decimal{value: new(big.Int).Sub(a, b), scale: scale}
+}
+
+func (d Decimal) Mul(e Decimal) Decimal {
+	return Decimal{value: new(big.Int).Mul(d.unscaled(), e.unscaled()), scale: d.scale + e.scale}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Int).Neg(d.unscaled()), scale: d.scale}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{value: new(big.Int).Abs(d.unscaled()), scale: d.scale}
+}
+
+// Div divides exactly and rounds the quotient to scale digits after the point.
+func (d Decimal) Div(e Decimal, scale int32, mode RoundingMode) (Decimal, error) {
+	if e.Sign() == 0 {
+		return Decimal{}, ErrDivisionByZero
+	}
+	quotient := new(big.Rat).SetFrac(d.unscaled(), e.unscaled())
+	shift := int64(scale) - int64(d.scale) + int64(e.scale)
+	return roundRat(quotient, shift, scale, mode), nil
+}
+
+// Mod returns the remainder of truncated division, carrying the sign of d.
+func (d Decimal) Mod(e Decimal) (Decimal, error) {
+	if e.Sign() == 0 {
+		return Decimal{}, ErrDivisionByZero
+	}
+	a, b, scale := align(d, e)
+	return Decimal{value: new(big.Int).Rem(a, b), scale: scale}, nil
+}
+
+func (d Decimal) Round(scale int32, mode RoundingMode) Decimal {
+	if scale >= d.scale {
+		return d
+	}
+	rat := new(big.Rat).SetInt(d.unscaled())
+	return roundRat(rat, int64(scale)-int64(d.scale), scale, mode)
+}
+
+func (d Decimal) Floor() Decimal {
+	return d.Round(0, Floor)
+}
+
+func (d Decimal) Ceil() Decimal {
+	return d.Round(0, Ceiling)
+}
+
+// roundRat rounds r * 10^shift to an integer and returns it as a decimal with the given scale.
+func roundRat(r *big.Rat, shift int64, scale int32, mode RoundingMode) Decimal {
+	num := new(big.Int).Set(r.Num())
+	den := new(big.Int).Set(r.Denom())
+	if shift > 0 {
+		num.Mul(num, new(big.Int).Exp(bigTen, big.NewInt(shift), nil))
+	} else if shift < 0 {
+		den.Mul(den, new(big.Int).Exp(bigTen, big.NewInt(-shift), nil))
+	}
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if remainder.Sign() == 0 {
+		return Decimal{value: quotient, scale: scale}
+	}
+	sign := num.Sign()
+	twice := new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2))
+	half := twice.Cmp(den)
+	awayFromZero := false
+	switch mode {
+	case Up:
+		awayFromZero = true
+	case Down:
+		awayFromZero = false
+	case Ceiling:
+		awayFromZero = sign > 0
+	case Floor:
+		awayFromZero = sign < 0
+	case HalfUp:
+		awayFromZero = half >= 0
+	case HalfDown:
+		awayFromZero = half > 0
+	case HalfEven:
+		awayFromZero = half > 0 || (half == 0 && quotient.Bit(0) == 1)
+	}
+	if awayFromZero {
+		if sign < 0 {
+			quotient.Sub(quotient, bigOne)
+		} else {
+			quotient.Add(quotient, bigOne)
+		}
+	}
+	return Decimal{value: quotient, scale: scale}
+}
+
+func (d Decimal) Cmp(e Decimal) int {
+	a, b, _ := align(d, e)
+	return a.Cmp(b)
+}
+
+func (d Decimal) Equal(e Decimal) bool {
+	return d.Cmp(e) == 0
+}
+
+func (d Decimal) Sign() int {
+	return d.unscaled().Sign()
+}
+
+func (d Decimal) IsInteger() bool {
+	if d.scale <= 0 {
+		return true
+	}
+	divisor := new(big.Int).Exp(bigTen, big.NewInt(int64(d.scale)), nil)
+	return new(big.Int).Rem(d.unscaled(), divisor).Sign() == 0
+}
+
+func (d Decimal) Rat() *big.Rat {
+	rat := new(big.Rat).SetInt(d.unscaled())
+	if d.scale > 0 {
+		return rat.Quo(rat, new(big.Rat).SetInt(new(big.Int).Exp(bigTen, big.NewInt(int64(d.scale)), nil)))
+	}
+	if d.scale < 0 {
+		return rat.Mul(rat, new(big.Rat).SetInt(new(big.Int).Exp(bigTen, big.NewInt(int64(-d.scale)), nil)))
+	}
+	return rat
+}
+
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// String prints the number without an exponent and without trailing zeros.
+func (d Decimal) String() string {
+	value := d.unscaled()
+	if d.scale <= 0 {
+		return d.rescale(0).String()
+	}
+	digits := new(big.Int).Abs(value).String()
+	if len(digits) <= int(d.scale) {
+		digits = strings.Repeat("0", int(d.scale)-len(digits)+1) + digits
+	}
+	point := len(digits) - int(d.scale)
+	integer, fraction := digits[:point], strings.TrimRight(digits[point:], "0")
+	s := integer
+	if fraction != "" {
+		s += "." + fraction
+	}
+	if value.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	parsed, err := NewFromString(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
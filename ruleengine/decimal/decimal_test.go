@@ -0,0 +1,97 @@
+package decimal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewFromString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{input: "100", expected: "100"},
+		{input: "-0.015", expected: "-0.015"},
+		{input: "1.50", expected: "1.5"},
+		{input: "1e3", expected: "1000"},
+		{input: "2.5E-2", expected: "0.025"},
+		{input: ".5", expected: "0.5"},
+		{input: "abc", wantErr: true},
+		{input: "1-2", wantErr: true},
+		{input: "", wantErr: true},
+		{input: "1e10000", expected: "1" + strings.Repeat("0", 10000)},
+		{input: "1e10001", wantErr: true},
+		{input: "1e-50000000", wantErr: true},
+		{input: "1e2147483647", wantErr: true},
+		{input: "0." + strings.Repeat("0", 10000) + "1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			d, err := NewFromString(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err == nil && d.String() != tt.expected {
+				t.Errorf("Unexpected output. Expected: %s, Got: %s", tt.expected, d.String())
+			}
+		})
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	a := RequireFromString("0.1")
+	b := RequireFromString("0.2")
+	if got := a.Add(b); !got.Equal(RequireFromString("0.3")) {
+		t.Errorf("Unexpected sum: %s", got)
+	}
+	if got := RequireFromString("1000000").Mul(RequireFromString("0.015")); got.String() != "15000" {
+		t.Errorf("Unexpected product: %s", got)
+	}
+	if got, _ := NewFromInt(1).Div(NewFromInt(3), 4, HalfUp); got.String() != "0.3333" {
+		t.Errorf("Unexpected quotient: %s", got)
+	}
+	if _, err := NewFromInt(1).Div(Zero, 4, HalfUp); err != ErrDivisionByZero {
+		t.Errorf("Expected division by zero, Got: %v", err)
+	}
+	if got, _ := RequireFromString("7.5").Mod(NewFromInt(2)); got.String() != "1.5" {
+		t.Errorf("Unexpected remainder: %s", got)
+	}
+}
+
+func TestDecimal_Round(t *testing.T) {
+	tests := []struct {
+		input    string
+		mode     RoundingMode
+		expected string
+	}{
+		{input: "2.5", mode: HalfUp, expected: "3"},
+		{input: "2.5", mode: HalfEven, expected: "2"},
+		{input: "3.5", mode: HalfEven, expected: "4"},
+		{input: "2.5", mode: HalfDown, expected: "2"},
+		{input: "-2.5", mode: HalfUp, expected: "-3"},
+		{input: "2.1", mode: Up, expected: "3"},
+		{input: "2.9", mode: Down, expected: "2"},
+		{input: "-2.1", mode: Ceiling, expected: "-2"},
+		{input: "-2.1", mode: Floor, expected: "-3"},
+	}
+	for _, tt := range tests {
+		got := RequireFromString(tt.input).Round(0, tt.mode)
+		if got.String() != tt.expected {
+			t.Errorf("Round(%s, %d). Expected: %s, Got: %s", tt.input, tt.mode, tt.expected, got)
+		}
+	}
+}
+
+func TestDecimal_Cmp(t *testing.T) {
+	if RequireFromString("100").Cmp(RequireFromString("100.5")) >= 0 {
+		t.Errorf("Expected 100 < 100.5")
+	}
+	if !RequireFromString("1.10").Equal(RequireFromString("1.1")) {
+		t.Errorf("Expected 1.10 == 1.1")
+	}
+	f, _ := NewFromFloat(0.1)
+	if !f.Equal(RequireFromString("0.1")) {
+		t.Errorf("Expected float 0.1 to convert exactly, Got: %s", f)
+	}
+}
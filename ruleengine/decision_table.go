@@ -0,0 +1,416 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/decimal"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/finding-kind"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/hit-policy"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"sort"
+)
+
+const (
+	// tableWildcard is the cell that matches any value, like an empty cell.
+	tableWildcard = "-"
+	// tableOtherValue stands for any value not mentioned in a column when
+	// looking for missing rows.
+	tableOtherValue = "(other)"
+	// maxTableSamples bounds the combinations of representative values checked
+	// by AnalyzeDecisionTable.
+	maxTableSamples = 100000
+	maxTableGaps    = 10
+)
+
+// DecisionTable is a set of rows, each comparing the input columns with its cells
+// and producing the values of the output columns when they all match. The hit
+// policy decides which matching rows produce outputs.
+type DecisionTable struct {
+	Name      string        `json:"name,omitempty"`
+	HitPolicy string        `json:"hit_policy"`
+	Inputs    []TableInput  `json:"inputs"`
+	Outputs   []TableOutput `json:"outputs"`
+	Rows      []TableRow    `json:"rows"`
+}
+
+// TableInput compares the Name field with the cells of its column using Operator,
+// which defaults to equals.
+type TableInput struct {
+	Name     string `json:"name"`
+	Operator string `json:"operator,omitempty"`
+}
+
+// TableOutput lists the output values from highest to lowest priority for the
+// PRIORITY hit policy.
+type TableOutput struct {
+	Name       string        `json:"name"`
+	Priorities []interface{} `json:"priorities,omitempty"`
+}
+
+// TableRow compiles to a rule with ID, or with its position from 1 when ID is 0.
+// An input cell is either a value compared using the column operator or a
+// Condition whose predicates without a name compare the column's field.
+type TableRow struct {
+	ID      int           `json:"id,omitempty"`
+	Inputs  []interface{} `json:"inputs"`
+	Outputs []interface{} `json:"outputs"`
+}
+
+type TableResult struct {
+	Outputs []map[string]interface{} `json:"outputs"`
+	Rows    []int                    `json:"rows"`
+}
+
+type DecisionTableProcessor struct {
+	table  DecisionTable
+	rules  []Rule
+	engine *engine
+}
+
+func ParseJsonDecisionTable(tableStr string) (DecisionTable, error) {
+	var table DecisionTable
+	err := json.Unmarshal([]byte(tableStr), &table)
+	return table, err
+}
+
+func (c TableInput) operator() string {
+	if c.Operator == "" {
+		return operators.Equals
+	}
+	return c.Operator
+}
+
+func (c TableInput) condition(cell interface{}) Condition {
+	if condition, ok := cell.(Condition); ok {
+		return bindTableCondition(condition, c.Name)
+	}
+	return NewCondition(c.Name, c.operator(), cell)
+}
+
+// bindTableCondition names the predicates of a cell condition after its column.
+func bindTableCondition(condition Condition, name string) Condition {
+	if condition.LogicalOperator == "" {
+		if condition.Name == "" {
+			condition.Name = name
+		}
+		return condition
+	}
+	conditions := make([]Condition, 0, len(condition.Conditions))
+	for _, subCondition := range condition.Conditions {
+		conditions = append(conditions, bindTableCondition(subCondition, name))
+	}
+	condition.Conditions = conditions
+	return condition
+}
+
+func isTableWildcard(cell interface{}) bool {
+	return cell == nil || cell == tableWildcard
+}
+
+// Compile converts every row to a rule whose condition is the AND of its
+// non-wildcard cells.
+func (t DecisionTable) Compile() ([]Rule, error) {
+	switch t.HitPolicy {
+	case hitpolicies.Unique, hitpolicies.First, hitpolicies.Priority, hitpolicies.Collect, hitpolicies.RuleOrder:
+	default:
+		return nil, fmt.Errorf("unknown hit policy %q", t.HitPolicy)
+	}
+	if len(t.Inputs) == 0 {
+		return nil, errors.New("decision table requires at least one input column")
+	}
+	for _, column := range t.Inputs {
+		if column.Name == "" {
+			return nil, errors.New("input column requires a name")
+		}
+	}
+	for _, column := range t.Outputs {
+		if column.Name == "" {
+			return nil, errors.New("output column requires a name")
+		}
+	}
+	rules := make([]Rule, 0, len(t.Rows))
+	ids := make(map[int]bool, len(t.Rows))
+	for i, row := range t.Rows {
+		id := row.ID
+		if id == 0 {
+			id = i + 1
+		}
+		if ids[id] {
+			return nil, fmt.Errorf("row %d: duplicate rule id %d", i+1, id)
+		}
+		ids[id] = true
+		if len(row.Inputs) != len(t.Inputs) {
+			return nil, fmt.Errorf("row %d has %d input cells, expected %d", i+1, len(row.Inputs), len(t.Inputs))
+		}
+		if len(row.Outputs) != len(t.Outputs) {
+			return nil, fmt.Errorf("row %d has %d output cells, expected %d", i+1, len(row.Outputs), len(t.Outputs))
+		}
+		condition := Condition{LogicalOperator: logicaloperators.And}
+		for j, cell := range row.Inputs {
+			if isTableWildcard(cell) {
+				continue
+			}
+			condition.Conditions = append(condition.Conditions, t.Inputs[j].condition(cell))
+		}
+		rules = append(rules, Rule{ID: id, Condition: condition})
+	}
+	return rules, nil
+}
+
+// RuleSet compiles the table to a rule set that is valid when any row matches.
+func (t DecisionTable) RuleSet() (RuleSet, error) {
+	rules, err := t.Compile()
+	if err != nil {
+		return RuleSet{}, err
+	}
+	ruleSet := RuleSet{LogicalOperator: logicaloperators.Or}
+	for _, rule := range rules {
+		ruleSet.Rules = append(ruleSet.Rules, rule)
+	}
+	return ruleSet, nil
+}
+
+func NewDecisionTableProcessor(table DecisionTable, opts ...Option) (*DecisionTableProcessor, error) {
+	rules, err := table.Compile()
+	if err != nil {
+		return nil, err
+	}
+	re := NewRuleEngine(opts...).(*engine)
+	ruleSet := RuleSet{}
+	for _, rule := range rules {
+		ruleSet.Rules = append(ruleSet.Rules, rule)
+	}
+	if err = re.prepareRuleSet(ruleSet); err != nil {
+		return nil, err
+	}
+	if len(re.windows) > 0 {
+		return nil, errors.New("window conditions cannot be used in decision tables")
+	}
+	return &DecisionTableProcessor{table: table, rules: rules, engine: re}, nil
+}
+
+func (p *DecisionTableProcessor) Rules() []Rule {
+	return p.rules
+}
+
+// Evaluate returns the outputs of the rows selected by the hit policy. UNIQUE
+// tables return an error when more than one row matches.
+func (p *DecisionTableProcessor) Evaluate(input map[string]interface{}) (TableResult, error) {
+	if err := p.engine.validateInput(input); err != nil {
+		return TableResult{}, err
+	}
+	matched := p.matchingRows(input)
+	result := TableResult{Outputs: []map[string]interface{}{}, Rows: []int{}}
+	if len(matched) == 0 {
+		return result, nil
+	}
+	switch p.table.HitPolicy {
+	case hitpolicies.Unique:
+		if len(matched) > 1 {
+			return TableResult{}, fmt.Errorf("hit policy %s violated: rows %d and %d both match", hitpolicies.Unique, p.rules[matched[0]].ID, p.rules[matched[1]].ID)
+		}
+	case hitpolicies.First:
+		matched = matched[:1]
+	case hitpolicies.Priority:
+		matched = []int{p.highestPriority(matched)}
+	}
+	for _, row := range matched {
+		result.Rows = append(result.Rows, p.rules[row].ID)
+		result.Outputs = append(result.Outputs, p.rowOutputs(row))
+	}
+	return result, nil
+}
+
+// matchingRows returns the indexes of the rows matching the input.
+func (p *DecisionTableProcessor) matchingRows(input map[string]interface{}) []int {
+	var matched []int
+	for i, rule := range p.rules {
+		if p.engine.evaluateConditions(input, rule.Condition) {
+			matched = append(matched, i)
+		}
+	}
+	return matched
+}
+
+func (p *DecisionTableProcessor) rowOutputs(row int) map[string]interface{} {
+	outputs := make(map[string]interface{}, len(p.table.Outputs))
+	for i, column := range p.table.Outputs {
+		outputs[column.Name] = p.table.Rows[row].Outputs[i]
+	}
+	return outputs
+}
+
+// highestPriority compares the outputs of the rows column by column by their
+// position in the column's priorities, keeping the earlier row on ties.
+func (p *DecisionTableProcessor) highestPriority(rows []int) int {
+	best := rows[0]
+	for _, row := range rows[1:] {
+		for i, column := range p.table.Outputs {
+			if len(column.Priorities) == 0 {
+				continue
+			}
+			rank := p.priorityRank(column, p.table.Rows[row].Outputs[i])
+			bestRank := p.priorityRank(column, p.table.Rows[best].Outputs[i])
+			if rank != bestRank {
+				if rank < bestRank {
+					best = row
+				}
+				break
+			}
+		}
+	}
+	return best
+}
+
+func (p *DecisionTableProcessor) priorityRank(column TableOutput, value interface{}) int {
+	for i, priority := range column.Priorities {
+		if p.engine.isEqual(value, priority) {
+			return i
+		}
+	}
+	return len(column.Priorities)
+}
+
+// AnalyzeDecisionTable evaluates the table on representative inputs built from
+// the values of its cells and reports inputs no row matches and, for UNIQUE
+// tables, rows matching the same input. Every finding carries such an input.
+func AnalyzeDecisionTable(table DecisionTable) (AnalysisReport, error) {
+	processor, err := NewDecisionTableProcessor(table)
+	if err != nil {
+		return AnalysisReport{}, err
+	}
+	path := table.Name
+	if path == "" {
+		path = "$"
+	}
+	a := &analyzer{re: processor.engine}
+
+	fields, points := processor.samplePoints()
+	combinations := 1
+	for _, field := range fields {
+		combinations *= len(points[field])
+		if combinations > maxTableSamples {
+			a.addFinding(findingkinds.TooComplex, path, fmt.Sprintf("table has more than %d combinations of values and was not analyzed", maxTableSamples))
+			return a.report, nil
+		}
+	}
+
+	overlaps := make(map[[2]int]bool)
+	gaps := 0
+	indices := make([]int, len(fields))
+	for {
+		input := make(map[string]interface{}, len(fields))
+		for i, field := range fields {
+			input[field] = points[field][indices[i]]
+		}
+		matched := processor.matchingRows(input)
+		if len(matched) == 0 && gaps < maxTableGaps {
+			gaps++
+			a.addFinding(findingkinds.Gap, path, fmt.Sprintf("no row matches %s", describeSample(input)))
+		}
+		if table.HitPolicy == hitpolicies.Unique {
+			for i := 0; i < len(matched); i++ {
+				for j := i + 1; j < len(matched); j++ {
+					pair := [2]int{processor.rules[matched[i]].ID, processor.rules[matched[j]].ID}
+					if overlaps[pair] {
+						continue
+					}
+					overlaps[pair] = true
+					a.addFinding(findingkinds.Overlap, path, fmt.Sprintf("rows %d and %d both match %s", pair[0], pair[1], describeSample(input)), pair[0], pair[1])
+				}
+			}
+		}
+
+		next := len(indices) - 1
+		for next >= 0 {
+			indices[next]++
+			if indices[next] < len(points[fields[next]]) {
+				break
+			}
+			indices[next] = 0
+			next--
+		}
+		if next < 0 {
+			break
+		}
+	}
+	return a.report, nil
+}
+
+// samplePoints picks, for every field compared by a non-wildcard cell, values
+// covering every region its cells distinguish: each number, the midpoints
+// between them and values beyond both ends, each other value and a value none
+// of the cells mention.
+func (p *DecisionTableProcessor) samplePoints() ([]string, map[string][]interface{}) {
+	values := make(map[string][]interface{})
+	for _, rule := range p.rules {
+		_ = walkCondition(rule.Condition, func(condition Condition) error {
+			if condition.LogicalOperator != "" {
+				return nil
+			}
+			if condition.Operator == operators.In {
+				if cellValues, ok := condition.Value.([]interface{}); ok {
+					values[condition.Name] = append(values[condition.Name], cellValues...)
+					return nil
+				}
+			}
+			values[condition.Name] = append(values[condition.Name], condition.Value)
+			return nil
+		})
+	}
+
+	fields := make([]string, 0, len(values))
+	points := make(map[string][]interface{}, len(values))
+	for field, fieldValues := range values {
+		fields = append(fields, field)
+		var (
+			numbers []decimal.Decimal
+			others  []interface{}
+		)
+		seen := make(map[string]bool)
+		for _, value := range fieldValues {
+			key := valueKey(value)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if number, ok := toDecimal(value); ok {
+				numbers = append(numbers, number)
+			} else {
+				others = append(others, value)
+			}
+		}
+		if len(numbers) > 0 {
+			sort.Slice(numbers, func(i, j int) bool {
+				return numbers[i].Cmp(numbers[j]) < 0
+			})
+			one := decimal.NewFromInt(1)
+			two := decimal.NewFromInt(2)
+			points[field] = append(points[field], numbers[0].Sub(one))
+			for i, number := range numbers {
+				points[field] = append(points[field], number)
+				if i+1 < len(numbers) {
+					midpoint, _ := number.Add(numbers[i+1]).Div(two, divisionScale, decimal.HalfEven)
+					points[field] = append(points[field], midpoint)
+				}
+			}
+			points[field] = append(points[field], numbers[len(numbers)-1].Add(one))
+		}
+		if len(others) > 0 {
+			points[field] = append(points[field], others...)
+			points[field] = append(points[field], tableOtherValue)
+		}
+	}
+	sort.Strings(fields)
+	return fields, points
+}
+
+func describeSample(input map[string]interface{}) string {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Sprint(input)
+	}
+	return string(data)
+}
@@ -0,0 +1,144 @@
+package ruleengine
+
+import (
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/aggregate-function"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/finding-kind"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/hit-policy"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"reflect"
+	"testing"
+)
+
+func feeTable(hitPolicy string) DecisionTable {
+	return DecisionTable{
+		Name:      "fees",
+		HitPolicy: hitPolicy,
+		Inputs: []TableInput{
+			{Name: "channel"},
+			{Name: "amount", Operator: operators.GreaterThanEquals},
+			{Name: "amount", Operator: operators.LessThan},
+		},
+		Outputs: []TableOutput{
+			{Name: "fee"},
+			{Name: "review", Priorities: []interface{}{"manual", "auto"}},
+		},
+		Rows: []TableRow{
+			{Inputs: []interface{}{"web", 0, 1000}, Outputs: []interface{}{1, "auto"}},
+			{Inputs: []interface{}{"web", 1000, nil}, Outputs: []interface{}{2, "auto"}},
+			{Inputs: []interface{}{"-", 5000, nil}, Outputs: []interface{}{5, "manual"}},
+			{Inputs: []interface{}{"branch", nil, nil}, Outputs: []interface{}{0, "auto"}},
+		},
+	}
+}
+
+func Test_DecisionTableProcessor_Evaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		hitPolicy string
+		input     map[string]interface{}
+		expected  TableResult
+		wantErr   bool
+	}{
+		{
+			name:      "Unique",
+			hitPolicy: hitpolicies.Unique,
+			input:     map[string]interface{}{"channel": "web", "amount": 500},
+			expected:  TableResult{Outputs: []map[string]interface{}{{"fee": 1, "review": "auto"}}, Rows: []int{1}},
+		},
+		{
+			name:      "Unique violated",
+			hitPolicy: hitpolicies.Unique,
+			input:     map[string]interface{}{"channel": "web", "amount": 6000},
+			wantErr:   true,
+		},
+		{
+			name:      "No match",
+			hitPolicy: hitpolicies.Unique,
+			input:     map[string]interface{}{"channel": "mobile", "amount": 500},
+			expected:  TableResult{Outputs: []map[string]interface{}{}, Rows: []int{}},
+		},
+		{
+			name:      "First",
+			hitPolicy: hitpolicies.First,
+			input:     map[string]interface{}{"channel": "web", "amount": 6000},
+			expected:  TableResult{Outputs: []map[string]interface{}{{"fee": 2, "review": "auto"}}, Rows: []int{2}},
+		},
+		{
+			name:      "Priority",
+			hitPolicy: hitpolicies.Priority,
+			input:     map[string]interface{}{"channel": "web", "amount": 6000},
+			expected:  TableResult{Outputs: []map[string]interface{}{{"fee": 5, "review": "manual"}}, Rows: []int{3}},
+		},
+		{
+			name:      "Rule order",
+			hitPolicy: hitpolicies.RuleOrder,
+			input:     map[string]interface{}{"channel": "branch", "amount": 6000},
+			expected:  TableResult{Outputs: []map[string]interface{}{{"fee": 5, "review": "manual"}, {"fee": 0, "review": "auto"}}, Rows: []int{3, 4}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor, err := NewDecisionTableProcessor(feeTable(tt.hitPolicy))
+			if err != nil {
+				t.Fatalf("Error creating processor: %v", err)
+			}
+			output, err := processor.Evaluate(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(output, tt.expected) {
+				t.Errorf("Unexpected output.\nExpected: %+v\nGot:      %+v", tt.expected, output)
+			}
+		})
+	}
+}
+
+func Test_DecisionTable_Compile(t *testing.T) {
+	rules, err := feeTable(hitpolicies.Collect).Compile()
+	if err != nil {
+		t.Fatalf("Error compiling table: %v", err)
+	}
+	expected := NewGroupCondition("AND", NewCondition("amount", operators.GreaterThanEquals, 5000))
+	if len(rules) != 4 || rules[2].ID != 3 || !reflect.DeepEqual(rules[2].Condition, expected) {
+		t.Errorf("Unexpected rules: %+v", rules)
+	}
+
+	invalid := feeTable("ANY")
+	if _, err = invalid.Compile(); err == nil {
+		t.Errorf("Expected unknown hit policy to be rejected")
+	}
+	invalid = feeTable(hitpolicies.First)
+	invalid.Rows[0].Inputs = invalid.Rows[0].Inputs[:1]
+	if _, err = invalid.Compile(); err == nil {
+		t.Errorf("Expected short row to be rejected")
+	}
+
+	windowed := feeTable(hitpolicies.First)
+	windowed.Rows[0].Inputs[0] = NewWindowCondition(Window{Function: aggregatefunctions.Count, Key: "channel", Duration: "1h"}, operators.GreaterThan, 3)
+	if _, err = NewDecisionTableProcessor(windowed); err == nil || err.Error() != "window conditions cannot be used in decision tables" {
+		t.Errorf("Expected window conditions to be rejected, got %v", err)
+	}
+}
+
+func Test_AnalyzeDecisionTable(t *testing.T) {
+	report, err := AnalyzeDecisionTable(feeTable(hitpolicies.Unique))
+	if err != nil {
+		t.Fatalf("Error analyzing table: %v", err)
+	}
+	gap := func(input string) Finding {
+		return Finding{Kind: findingkinds.Gap, Path: "fees", Message: "no row matches " + input}
+	}
+	expected := []Finding{
+		gap(`{"amount":-1,"channel":"web"}`),
+		gap(`{"amount":-1,"channel":"(other)"}`),
+		gap(`{"amount":0,"channel":"(other)"}`),
+		gap(`{"amount":500,"channel":"(other)"}`),
+		gap(`{"amount":1000,"channel":"(other)"}`),
+		gap(`{"amount":3000,"channel":"(other)"}`),
+		{Kind: findingkinds.Overlap, Path: "fees", RuleIDs: []int{2, 3}, Message: `rows 2 and 3 both match {"amount":5000,"channel":"web"}`},
+		{Kind: findingkinds.Overlap, Path: "fees", RuleIDs: []int{3, 4}, Message: `rows 3 and 4 both match {"amount":5000,"channel":"branch"}`},
+	}
+	if !reflect.DeepEqual(report.Findings, expected) {
+		t.Errorf("Unexpected findings.\nExpected: %+v\nGot:      %+v", expected, report.Findings)
+	}
+}
@@ -0,0 +1,297 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	treeRootID        = "root"
+	treeTrueBranch    = "true"
+	treeFalseBranch   = "false"
+	treeDefaultBranch = "default"
+)
+
+// DecisionTree splits on the condition of each node until it reaches a leaf,
+// whose outcome is the result of the tree.
+type DecisionTree struct {
+	Name string   `json:"name,omitempty"`
+	Root TreeNode `json:"root"`
+}
+
+// TreeNode is a leaf when it has neither a condition nor branches. A node with a
+// condition follows True or False; a node with branches follows the first branch
+// whose condition holds, or Default. A missing child ends the evaluation without
+// an outcome. Nodes without an ID are named after their path from the root, such
+// as root.true.2.
+type TreeNode struct {
+	ID        string       `json:"id,omitempty"`
+	Condition *Condition   `json:"condition,omitempty"`
+	True      *TreeNode    `json:"true,omitempty"`
+	False     *TreeNode    `json:"false,omitempty"`
+	Branches  []TreeBranch `json:"branches,omitempty"`
+	Default   *TreeNode    `json:"default,omitempty"`
+	Outcome   interface{}  `json:"outcome,omitempty"`
+}
+
+// TreeBranch is one way of a multi-way node. Label names the branch in paths and
+// diagrams and defaults to its position from 1.
+type TreeBranch struct {
+	Label     string    `json:"label,omitempty"`
+	Condition Condition `json:"condition"`
+	Node      TreeNode  `json:"node"`
+}
+
+// TreeResult holds the outcome of the leaf reached, if any, and the nodes
+// visited from the root with the branch taken at each.
+type TreeResult struct {
+	Outcome interface{} `json:"outcome"`
+	Matched bool        `json:"matched"`
+	Path    []TreeStep  `json:"path"`
+}
+
+type TreeStep struct {
+	Node   string `json:"node"`
+	Branch string `json:"branch,omitempty"`
+}
+
+type DecisionTreeProcessor struct {
+	tree   DecisionTree
+	engine *engine
+}
+
+func ParseJsonDecisionTree(treeStr string) (DecisionTree, error) {
+	var tree DecisionTree
+	err := json.Unmarshal([]byte(treeStr), &tree)
+	return tree, err
+}
+
+// ExportDecisionTreeJson writes the tree in the format read by ParseJsonDecisionTree.
+func ExportDecisionTreeJson(w io.Writer, tree DecisionTree) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(tree)
+}
+
+func (n *TreeNode) isLeaf() bool {
+	return n.Condition == nil && len(n.Branches) == 0
+}
+
+func (b TreeBranch) label(position int) string {
+	if b.Label != "" {
+		return b.Label
+	}
+	return strconv.Itoa(position + 1)
+}
+
+func treeNodeID(node *TreeNode, path string) string {
+	if node.ID != "" {
+		return node.ID
+	}
+	return path
+}
+
+// walkTree visits every node depth first with its ID.
+func walkTree(node *TreeNode, id string, visit func(node *TreeNode, id string) error) error {
+	var walk func(node *TreeNode, path string) error
+	walk = func(node *TreeNode, path string) error {
+		id := treeNodeID(node, path)
+		if err := visit(node, id); err != nil {
+			return err
+		}
+		if node.True != nil {
+			if err := walk(node.True, id+"."+treeTrueBranch); err != nil {
+				return err
+			}
+		}
+		if node.False != nil {
+			if err := walk(node.False, id+"."+treeFalseBranch); err != nil {
+				return err
+			}
+		}
+		for i := range node.Branches {
+			if err := walk(&node.Branches[i].Node, id+"."+node.Branches[i].label(i)); err != nil {
+				return err
+			}
+		}
+		if node.Default != nil {
+			return walk(node.Default, id+"."+treeDefaultBranch)
+		}
+		return nil
+	}
+	return walk(node, id)
+}
+
+func NewDecisionTreeProcessor(tree DecisionTree, opts ...Option) (*DecisionTreeProcessor, error) {
+	re := NewRuleEngine(opts...).(*engine)
+	ids := make(map[string]bool)
+	err := walkTree(&tree.Root, treeRootID, func(node *TreeNode, id string) error {
+		if ids[id] {
+			return fmt.Errorf("duplicate node id %s", id)
+		}
+		ids[id] = true
+		if node.Condition != nil && len(node.Branches) > 0 {
+			return fmt.Errorf("node %s has both a condition and branches", id)
+		}
+		if !node.isLeaf() && node.Outcome != nil {
+			return fmt.Errorf("node %s has an outcome but is not a leaf", id)
+		}
+		if node.Condition == nil && (node.True != nil || node.False != nil) {
+			return fmt.Errorf("node %s has true or false children but no condition", id)
+		}
+		if len(node.Branches) == 0 && node.Default != nil {
+			return fmt.Errorf("node %s has a default child but no branches", id)
+		}
+		conditions := make([]Condition, 0, len(node.Branches))
+		if node.Condition != nil {
+			conditions = append(conditions, *node.Condition)
+		}
+		for _, branch := range node.Branches {
+			conditions = append(conditions, branch.Condition)
+		}
+		for _, condition := range conditions {
+			if err := re.prepareRuleSet(RuleSet{Rules: []interface{}{Rule{Condition: implicitAnd(condition)}}}); err != nil {
+				return fmt.Errorf("node %s: %w", id, err)
+			}
+			if len(re.windows) > 0 {
+				return fmt.Errorf("node %s: window conditions cannot be used in decision trees", id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &DecisionTreeProcessor{tree: tree, engine: re}, nil
+}
+
+// Evaluate follows the tree from the root and returns the outcome of the leaf
+// reached together with the path taken.
+func (p *DecisionTreeProcessor) Evaluate(input map[string]interface{}) (TreeResult, error) {
+	if err := p.engine.validateInput(input); err != nil {
+		return TreeResult{}, err
+	}
+	result := TreeResult{Path: []TreeStep{}}
+	node, path := &p.tree.Root, treeRootID
+	for node != nil {
+		id := treeNodeID(node, path)
+		if node.isLeaf() {
+			result.Path = append(result.Path, TreeStep{Node: id})
+			result.Outcome = node.Outcome
+			result.Matched = true
+			break
+		}
+		var (
+			next   *TreeNode
+			branch string
+		)
+		if node.Condition != nil {
+			if p.engine.evaluateConditions(input, implicitAnd(*node.Condition)) {
+				next, branch = node.True, treeTrueBranch
+			} else {
+				next, branch = node.False, treeFalseBranch
+			}
+		} else {
+			next, branch = node.Default, treeDefaultBranch
+			for i := range node.Branches {
+				if p.engine.evaluateConditions(input, implicitAnd(node.Branches[i].Condition)) {
+					next, branch = &node.Branches[i].Node, node.Branches[i].label(i)
+					break
+				}
+			}
+		}
+		result.Path = append(result.Path, TreeStep{Node: id, Branch: branch})
+		node, path = next, id+"."+branch
+	}
+	return result, nil
+}
+
+// ExportDecisionTreeDOT renders the tree as a Graphviz digraph: a box labelled
+// with the condition of every true/false split, a diamond for every multi-way
+// node with its branch conditions on the edges and an ellipse for every leaf.
+func ExportDecisionTreeDOT(w io.Writer, tree DecisionTree) error {
+	var builder strings.Builder
+	name := tree.Name
+	if name == "" {
+		name = "decision_tree"
+	}
+	fmt.Fprintf(&builder, "digraph %s {\n", dotQuote(name))
+	builder.WriteString("  node [shape=box];\n")
+	err := walkTree(&tree.Root, treeRootID, func(node *TreeNode, id string) error {
+		switch {
+		case node.isLeaf():
+			fmt.Fprintf(&builder, "  %s [label=%s, shape=ellipse];\n", dotQuote(id), dotQuote(describeOutcome(node.Outcome)))
+		case node.Condition != nil:
+			fmt.Fprintf(&builder, "  %s [label=%s];\n", dotQuote(id), dotQuote(describeCondition(*node.Condition)))
+		default:
+			fmt.Fprintf(&builder, "  %s [label=%s, shape=diamond];\n", dotQuote(id), dotQuote(id))
+		}
+		edge := func(child *TreeNode, branch string, label string) {
+			childID := treeNodeID(child, id+"."+branch)
+			fmt.Fprintf(&builder, "  %s -> %s [label=%s];\n", dotQuote(id), dotQuote(childID), dotQuote(label))
+		}
+		if node.True != nil {
+			edge(node.True, treeTrueBranch, treeTrueBranch)
+		}
+		if node.False != nil {
+			edge(node.False, treeFalseBranch, treeFalseBranch)
+		}
+		for i := range node.Branches {
+			branch := node.Branches[i].label(i)
+			label := describeCondition(node.Branches[i].Condition)
+			if node.Branches[i].Label != "" {
+				label = branch + ": " + label
+			}
+			edge(&node.Branches[i].Node, branch, label)
+		}
+		if node.Default != nil {
+			edge(node.Default, treeDefaultBranch, treeDefaultBranch)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	builder.WriteString("}\n")
+	_, err = io.WriteString(w, builder.String())
+	return err
+}
+
+func dotQuote(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	text = strings.ReplaceAll(text, `"`, `\"`)
+	text = strings.ReplaceAll(text, "\n", `\n`)
+	return `"` + text + `"`
+}
+
+func describeOutcome(outcome interface{}) string {
+	if text, ok := outcome.(string); ok {
+		return text
+	}
+	return valueKey(outcome)
+}
+
+// describeCondition renders a condition for people, e.g.
+// (amount greater_than 1000 AND channel equals "web").
+func describeCondition(condition Condition) string {
+	condition = implicitAnd(condition)
+	if !isGroup(condition) {
+		return conditionKey(condition)
+	}
+	parts := make([]string, 0, len(condition.Conditions))
+	for _, subCondition := range condition.Conditions {
+		parts = append(parts, describeCondition(subCondition))
+	}
+	switch condition.LogicalOperator {
+	case logicaloperators.And, logicaloperators.Or, logicaloperators.Xor:
+		if len(parts) == 1 {
+			return parts[0]
+		}
+		return "(" + strings.Join(parts, " "+condition.LogicalOperator+" ") + ")"
+	}
+	return condition.LogicalOperator + "(" + strings.Join(parts, ", ") + ")"
+}
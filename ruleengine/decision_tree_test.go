@@ -0,0 +1,194 @@
+package ruleengine
+
+import (
+	"bytes"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const creditLimitTree = `{
+  "name": "credit_limit",
+  "root": {
+    "condition": {"name": "income", "operator": "greater_than_equals", "value": 5000},
+    "true": {
+      "id": "segment",
+      "branches": [
+        {"label": "prime", "condition": {"name": "score", "operator": "greater_than_equals", "value": 700}, "node": {"outcome": 20000}},
+        {"condition": {"logical_operator": "AND", "conditions": [
+          {"name": "score", "operator": "greater_than_equals", "value": 600},
+          {"name": "employed", "operator": "equals", "value": true}
+        ]}, "node": {"outcome": 10000}}
+      ],
+      "default": {"outcome": 2000}
+    },
+    "false": {
+      "condition": {"name": "score", "operator": "greater_than_equals", "value": 650},
+      "true": {"outcome": 1000}
+    }
+  }
+}`
+
+func Test_DecisionTreeProcessor_Evaluate(t *testing.T) {
+	tree, err := ParseJsonDecisionTree(creditLimitTree)
+	if err != nil {
+		t.Fatalf("Error parsing tree: %v", err)
+	}
+	processor, err := NewDecisionTreeProcessor(tree)
+	if err != nil {
+		t.Fatalf("Error compiling tree: %v", err)
+	}
+	tests := []struct {
+		name     string
+		input    map[string]interface{}
+		expected TreeResult
+	}{
+		{
+			name:  "Named branch",
+			input: map[string]interface{}{"income": 8000, "score": 720, "employed": true},
+			expected: TreeResult{Outcome: float64(20000), Matched: true, Path: []TreeStep{
+				{Node: "root", Branch: "true"}, {Node: "segment", Branch: "prime"}, {Node: "segment.prime"},
+			}},
+		},
+		{
+			name:  "Positional branch",
+			input: map[string]interface{}{"income": 8000, "score": 650, "employed": true},
+			expected: TreeResult{Outcome: float64(10000), Matched: true, Path: []TreeStep{
+				{Node: "root", Branch: "true"}, {Node: "segment", Branch: "2"}, {Node: "segment.2"},
+			}},
+		},
+		{
+			name:  "Default branch",
+			input: map[string]interface{}{"income": 8000, "score": 650, "employed": false},
+			expected: TreeResult{Outcome: float64(2000), Matched: true, Path: []TreeStep{
+				{Node: "root", Branch: "true"}, {Node: "segment", Branch: "default"}, {Node: "segment.default"},
+			}},
+		},
+		{
+			name:  "True and false splits",
+			input: map[string]interface{}{"income": 3000, "score": 680},
+			expected: TreeResult{Outcome: float64(1000), Matched: true, Path: []TreeStep{
+				{Node: "root", Branch: "false"}, {Node: "root.false", Branch: "true"}, {Node: "root.false.true"},
+			}},
+		},
+		{
+			name:  "Missing child",
+			input: map[string]interface{}{"income": 3000, "score": 600},
+			expected: TreeResult{Path: []TreeStep{
+				{Node: "root", Branch: "false"}, {Node: "root.false", Branch: "false"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := processor.Evaluate(tt.input)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func Test_NewDecisionTreeProcessor_Errors(t *testing.T) {
+	condition := NewCondition("score", operators.GreaterThan, 600)
+	tests := []struct {
+		name string
+		tree DecisionTree
+	}{
+		{
+			name: "Condition and branches",
+			tree: DecisionTree{Root: TreeNode{Condition: &condition, Branches: []TreeBranch{{Condition: condition}}}},
+		},
+		{
+			name: "Outcome on a split",
+			tree: DecisionTree{Root: TreeNode{Condition: &condition, Outcome: 1}},
+		},
+		{
+			name: "True child without condition",
+			tree: DecisionTree{Root: TreeNode{True: &TreeNode{Outcome: 1}}},
+		},
+		{
+			name: "Duplicate id",
+			tree: DecisionTree{Root: TreeNode{Condition: &condition, True: &TreeNode{ID: "leaf"}, False: &TreeNode{ID: "leaf"}}},
+		},
+		{
+			name: "Unknown logical operator",
+			tree: DecisionTree{Root: TreeNode{Condition: &Condition{LogicalOperator: "SOME", Conditions: []Condition{condition}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDecisionTreeProcessor(tt.tree); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+func Test_ExportDecisionTreeJson(t *testing.T) {
+	tree, err := ParseJsonDecisionTree(creditLimitTree)
+	if err != nil {
+		t.Fatalf("Error parsing tree: %v", err)
+	}
+	var buffer bytes.Buffer
+	if err = ExportDecisionTreeJson(&buffer, tree); err != nil {
+		t.Fatalf("Error exporting tree: %v", err)
+	}
+	imported, err := ParseJsonDecisionTree(buffer.String())
+	if err != nil {
+		t.Fatalf("Error parsing exported tree: %v", err)
+	}
+	if !reflect.DeepEqual(imported, tree) {
+		t.Errorf("Tree changed by the round trip.\nExpected: %+v\nGot:      %+v", tree, imported)
+	}
+}
+
+func Test_ExportDecisionTreeDOT(t *testing.T) {
+	score := NewCondition("score", operators.GreaterThanEquals, 700)
+	tree := DecisionTree{
+		Name: "limit",
+		Root: TreeNode{
+			Condition: &score,
+			True:      &TreeNode{Outcome: "approve"},
+			False: &TreeNode{
+				ID: "fallback",
+				Branches: []TreeBranch{{
+					Label: "web",
+					Condition: NewGroupCondition(logicaloperators.And,
+						NewCondition("channel", operators.Equals, "web"),
+						NewCondition("amount", operators.LessThan, 100),
+					),
+					Node: TreeNode{Outcome: 100},
+				}},
+				Default: &TreeNode{Outcome: "reject"},
+			},
+		},
+	}
+	var buffer bytes.Buffer
+	if err := ExportDecisionTreeDOT(&buffer, tree); err != nil {
+		t.Fatalf("Error exporting tree: %v", err)
+	}
+	expected := strings.Join([]string{
+		`digraph "limit" {`,
+		`  node [shape=box];`,
+		`  "root" [label="score greater_than_equals 700"];`,
+		`  "root" -> "root.true" [label="true"];`,
+		`  "root" -> "fallback" [label="false"];`,
+		`  "root.true" [label="approve", shape=ellipse];`,
+		`  "fallback" [label="fallback", shape=diamond];`,
+		`  "fallback" -> "fallback.web" [label="web: (channel equals \"web\" AND amount less_than 100)"];`,
+		`  "fallback" -> "fallback.default" [label="default"];`,
+		`  "fallback.web" [label="100", shape=ellipse];`,
+		`  "fallback.default" [label="reject", shape=ellipse];`,
+		`}`,
+		``,
+	}, "\n")
+	if buffer.String() != expected {
+		t.Errorf("Unexpected DOT output.\nExpected:\n%s\nGot:\n%s", expected, buffer.String())
+	}
+}
@@ -0,0 +1,473 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/hit-policy"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	feelNamePattern   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	feelNumberPattern = regexp.MustCompile(`^-?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+)
+
+// feelComparisons maps the FEEL comparison prefixes, longest first, to the
+// condition operators.
+var feelComparisons = []struct {
+	symbol   string
+	operator string
+}{
+	{"<=", operators.LessThanEquals},
+	{">=", operators.GreaterThanEquals},
+	{"!=", operators.NotEquals},
+	{"<", operators.LessThan},
+	{">", operators.GreaterThan},
+	{"=", operators.Equals},
+}
+
+// DMNImport holds the decision tables read from a DMN 1.3 model together with
+// the constructs that could not be translated. A decision using an unsupported
+// construct outside its rules is left out; a rule using one is left out of its
+// table.
+type DMNImport struct {
+	Decisions   []DMNDecision `json:"decisions"`
+	Unsupported []DMNIssue    `json:"unsupported"`
+}
+
+// DMNDecision is a decision of the model whose logic is a decision table. Its
+// rows keep the position of the DMN rule as ID, so skipped rules leave gaps.
+type DMNDecision struct {
+	ID    string        `json:"id,omitempty"`
+	Name  string        `json:"name"`
+	Table DecisionTable `json:"table"`
+}
+
+// DMNIssue describes an unsupported construct: the decision it was found in,
+// the rule numbered from 1 or 0 when it is outside the rules, the offending
+// text and why it was rejected.
+type DMNIssue struct {
+	Decision  string `json:"decision"`
+	Rule      int    `json:"rule,omitempty"`
+	Construct string `json:"construct"`
+	Reason    string `json:"reason"`
+}
+
+func (i DMNIssue) String() string {
+	if i.Rule == 0 {
+		return fmt.Sprintf("decision %s: %q: %s", i.Decision, i.Construct, i.Reason)
+	}
+	return fmt.Sprintf("decision %s, rule %d: %q: %s", i.Decision, i.Rule, i.Construct, i.Reason)
+}
+
+// Decision returns the imported decision with the given name.
+func (d DMNImport) Decision(name string) (DMNDecision, bool) {
+	for _, decision := range d.Decisions {
+		if decision.Name == name {
+			return decision, true
+		}
+	}
+	return DMNDecision{}, false
+}
+
+type dmnDefinitions struct {
+	XMLName   xml.Name      `xml:"definitions"`
+	Decisions []dmnDecision `xml:"decision"`
+}
+
+type dmnDecision struct {
+	ID       string            `xml:"id,attr"`
+	Name     string            `xml:"name,attr"`
+	Table    *dmnDecisionTable `xml:"decisionTable"`
+	Elements []dmnElement      `xml:",any"`
+}
+
+type dmnElement struct {
+	XMLName xml.Name
+}
+
+type dmnDecisionTable struct {
+	HitPolicy   string      `xml:"hitPolicy,attr"`
+	Aggregation string      `xml:"aggregation,attr"`
+	Inputs      []dmnInput  `xml:"input"`
+	Outputs     []dmnOutput `xml:"output"`
+	Rules       []dmnRule   `xml:"rule"`
+}
+
+type dmnInput struct {
+	Label      string  `xml:"label,attr"`
+	Expression dmnText `xml:"inputExpression"`
+}
+
+type dmnOutput struct {
+	Name   string   `xml:"name,attr"`
+	Label  string   `xml:"label,attr"`
+	Values *dmnText `xml:"outputValues"`
+}
+
+type dmnRule struct {
+	Inputs  []dmnText `xml:"inputEntry"`
+	Outputs []dmnText `xml:"outputEntry"`
+}
+
+type dmnText struct {
+	Text string `xml:"text"`
+}
+
+// dmnDecisionElements are the children of a decision that do not define its logic.
+var dmnDecisionElements = map[string]bool{
+	"description":                  true,
+	"extensionElements":            true,
+	"question":                     true,
+	"allowedAnswers":               true,
+	"variable":                     true,
+	"informationRequirement":       true,
+	"knowledgeRequirement":         true,
+	"authorityRequirement":         true,
+	"supportedObjective":           true,
+	"impactedPerformanceIndicator": true,
+	"decisionMaker":                true,
+	"decisionOwner":                true,
+	"usingProcess":                 true,
+	"usingTask":                    true,
+}
+
+// ImportDMN reads the decision tables of a DMN 1.3 model. Input expressions must
+// name a field, input entries are simple FEEL unary tests (literals, comparisons,
+// ranges, lists, not(...) and -) and output entries are literals. Everything else
+// is reported in Unsupported; only malformed XML is an error.
+func ImportDMN(r io.Reader) (DMNImport, error) {
+	var definitions dmnDefinitions
+	if err := xml.NewDecoder(r).Decode(&definitions); err != nil {
+		return DMNImport{}, fmt.Errorf("invalid DMN model: %w", err)
+	}
+	result := DMNImport{Decisions: []DMNDecision{}, Unsupported: []DMNIssue{}}
+	for _, decision := range definitions.Decisions {
+		imported, issues := importDMNDecision(decision)
+		result.Unsupported = append(result.Unsupported, issues...)
+		if imported != nil {
+			result.Decisions = append(result.Decisions, *imported)
+		}
+	}
+	return result, nil
+}
+
+func importDMNDecision(decision dmnDecision) (*DMNDecision, []DMNIssue) {
+	name := decision.Name
+	if name == "" {
+		name = decision.ID
+	}
+	issue := func(rule int, construct string, reason string) DMNIssue {
+		return DMNIssue{Decision: name, Rule: rule, Construct: construct, Reason: reason}
+	}
+	issueFrom := func(rule int, construct string, err error) DMNIssue {
+		var feelErr *feelError
+		if errors.As(err, &feelErr) {
+			return issue(rule, feelErr.text, feelErr.reason)
+		}
+		return issue(rule, construct, err.Error())
+	}
+	if decision.Table == nil {
+		for _, element := range decision.Elements {
+			if !dmnDecisionElements[element.XMLName.Local] {
+				return nil, []DMNIssue{issue(0, element.XMLName.Local, "only decision tables are supported")}
+			}
+		}
+		return nil, []DMNIssue{issue(0, "decision", "decision has no decision logic")}
+	}
+	source := decision.Table
+
+	table := DecisionTable{Name: name, HitPolicy: source.HitPolicy}
+	if table.HitPolicy == "" {
+		table.HitPolicy = hitpolicies.Unique
+	}
+	switch table.HitPolicy {
+	case hitpolicies.Unique, hitpolicies.First, hitpolicies.Priority, hitpolicies.Collect, hitpolicies.RuleOrder:
+	default:
+		return nil, []DMNIssue{issue(0, table.HitPolicy, "hit policy is not supported")}
+	}
+	if source.Aggregation != "" {
+		return nil, []DMNIssue{issue(0, table.HitPolicy+" "+source.Aggregation, "aggregation is not supported")}
+	}
+
+	for _, input := range source.Inputs {
+		expression := strings.TrimSpace(input.Expression.Text)
+		if !feelNamePattern.MatchString(expression) {
+			return nil, []DMNIssue{issue(0, expression, "input expressions must name a field")}
+		}
+		table.Inputs = append(table.Inputs, TableInput{Name: expression})
+	}
+	for _, output := range source.Outputs {
+		column := TableOutput{Name: output.Name}
+		if column.Name == "" {
+			column.Name = output.Label
+		}
+		if column.Name == "" && len(source.Outputs) == 1 {
+			column.Name = name
+		}
+		if output.Values != nil && strings.TrimSpace(output.Values.Text) != "" {
+			priorities, err := parseFEELLiterals(output.Values.Text)
+			if err != nil {
+				return nil, []DMNIssue{issueFrom(0, output.Values.Text, err)}
+			}
+			column.Priorities = priorities
+		}
+		table.Outputs = append(table.Outputs, column)
+	}
+
+	var issues []DMNIssue
+	for i, rule := range source.Rules {
+		row, err := importDMNRule(rule, len(table.Inputs), len(table.Outputs))
+		if err != nil {
+			issues = append(issues, issueFrom(i+1, "rule", err))
+			continue
+		}
+		row.ID = i + 1
+		table.Rows = append(table.Rows, row)
+	}
+	if _, err := table.Compile(); err != nil {
+		return nil, append(issues, issue(0, "decisionTable", err.Error()))
+	}
+	return &DMNDecision{ID: decision.ID, Name: name, Table: table}, issues
+}
+
+func importDMNRule(rule dmnRule, inputs int, outputs int) (TableRow, error) {
+	if len(rule.Inputs) != inputs {
+		return TableRow{}, fmt.Errorf("rule has %d input entries, expected %d", len(rule.Inputs), inputs)
+	}
+	if len(rule.Outputs) != outputs {
+		return TableRow{}, fmt.Errorf("rule has %d output entries, expected %d", len(rule.Outputs), outputs)
+	}
+	var row TableRow
+	for _, entry := range rule.Inputs {
+		cell, err := parseFEELUnaryTests(entry.Text)
+		if err != nil {
+			return TableRow{}, err
+		}
+		row.Inputs = append(row.Inputs, cell)
+	}
+	for _, entry := range rule.Outputs {
+		text := strings.TrimSpace(entry.Text)
+		if text == "" || text == "null" {
+			row.Outputs = append(row.Outputs, nil)
+			continue
+		}
+		value, err := parseFEELLiteral(text)
+		if err != nil {
+			return TableRow{}, err
+		}
+		row.Outputs = append(row.Outputs, value)
+	}
+	return row, nil
+}
+
+// feelError reports FEEL text outside the supported subset.
+type feelError struct {
+	text   string
+	reason string
+}
+
+func (e *feelError) Error() string {
+	return fmt.Sprintf("unsupported FEEL %q: %s", e.text, e.reason)
+}
+
+// parseFEELUnaryTests translates the unary tests of an input entry to a table
+// cell: nil for -, otherwise a Condition whose predicates have no name yet.
+func parseFEELUnaryTests(text string) (interface{}, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || text == tableWildcard {
+		return nil, nil
+	}
+	if strings.HasPrefix(text, "not(") && strings.HasSuffix(text, ")") {
+		inner := text[len("not(") : len(text)-1]
+		if parts, err := splitFEELList(inner); err == nil && len(parts) > 0 {
+			cell, err := parseFEELUnaryTests(inner)
+			if err != nil {
+				return nil, err
+			}
+			if cell == nil {
+				return nil, &feelError{text: text, reason: "negated wildcard matches nothing"}
+			}
+			return NewNotCondition(cell.(Condition)), nil
+		}
+	}
+
+	parts, err := splitFEELList(text)
+	if err != nil {
+		return nil, &feelError{text: text, reason: err.Error()}
+	}
+	conditions := make([]Condition, 0, len(parts))
+	values := make([]interface{}, 0, len(parts))
+	for _, part := range parts {
+		condition, err := parseFEELUnaryTest(part)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+		if condition.Operator == operators.Equals {
+			values = append(values, condition.Value)
+		}
+	}
+	if len(conditions) == 1 {
+		return conditions[0], nil
+	}
+	if len(values) == len(conditions) {
+		return NewCondition("", operators.In, values), nil
+	}
+	return NewGroupCondition(logicaloperators.Or, conditions...), nil
+}
+
+func parseFEELUnaryTest(text string) (Condition, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || text == tableWildcard {
+		return Condition{}, &feelError{text: text, reason: "- must be the only test of an entry"}
+	}
+	for _, comparison := range feelComparisons {
+		if !strings.HasPrefix(text, comparison.symbol) {
+			continue
+		}
+		endpoint := strings.TrimSpace(text[len(comparison.symbol):])
+		value, err := parseFEELLiteral(endpoint)
+		if err != nil {
+			return Condition{}, err
+		}
+		if comparison.operator != operators.Equals && comparison.operator != operators.NotEquals {
+			if _, ok := value.(float64); !ok {
+				return Condition{}, &feelError{text: text, reason: "only numbers can be ordered"}
+			}
+		}
+		return NewCondition("", comparison.operator, value), nil
+	}
+	if strings.Contains(text, "..") && strings.ContainsAny(text[:1], "[(]") && strings.ContainsAny(text[len(text)-1:], "])[") {
+		return parseFEELRange(text)
+	}
+	value, err := parseFEELLiteral(text)
+	if err != nil {
+		return Condition{}, err
+	}
+	return NewCondition("", operators.Equals, value), nil
+}
+
+// parseFEELRange translates an interval such as [1..10) or ]1..10] to the AND
+// of its bounds.
+func parseFEELRange(text string) (Condition, error) {
+	endpoints := strings.Split(text[1:len(text)-1], "..")
+	if len(endpoints) != 2 {
+		return Condition{}, &feelError{text: text, reason: "invalid range"}
+	}
+	bounds := make([]float64, 0, 2)
+	for _, endpoint := range endpoints {
+		value, err := parseFEELLiteral(strings.TrimSpace(endpoint))
+		if err != nil {
+			return Condition{}, err
+		}
+		number, ok := value.(float64)
+		if !ok {
+			return Condition{}, &feelError{text: text, reason: "only ranges of numbers are supported"}
+		}
+		bounds = append(bounds, number)
+	}
+	low := operators.GreaterThan
+	if text[0] == '[' {
+		low = operators.GreaterThanEquals
+	}
+	high := operators.LessThan
+	if text[len(text)-1] == ']' {
+		high = operators.LessThanEquals
+	}
+	return NewGroupCondition(logicaloperators.And,
+		NewCondition("", low, bounds[0]),
+		NewCondition("", high, bounds[1]),
+	), nil
+}
+
+// parseFEELLiteral reads a string, number or boolean literal.
+func parseFEELLiteral(text string) (interface{}, error) {
+	switch {
+	case text == "true":
+		return true, nil
+	case text == "false":
+		return false, nil
+	case strings.HasPrefix(text, `"`):
+		var value string
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			return nil, &feelError{text: text, reason: "invalid string literal"}
+		}
+		return value, nil
+	case feelNumberPattern.MatchString(text):
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, &feelError{text: text, reason: "invalid number"}
+		}
+		return value, nil
+	case strings.Contains(text, "("):
+		return nil, &feelError{text: text, reason: "function calls are not supported"}
+	case feelNamePattern.MatchString(text):
+		return nil, &feelError{text: text, reason: "references to other values are not supported"}
+	}
+	return nil, &feelError{text: text, reason: "only literals, comparisons, ranges and lists are supported"}
+}
+
+func parseFEELLiterals(text string) ([]interface{}, error) {
+	parts, err := splitFEELList(text)
+	if err != nil {
+		return nil, &feelError{text: text, reason: err.Error()}
+	}
+	values := make([]interface{}, 0, len(parts))
+	for _, part := range parts {
+		value, err := parseFEELLiteral(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// splitFEELList splits text on the commas outside string literals and function
+// calls. Parentheses not following a name are range bounds and do not nest.
+func splitFEELList(text string) ([]string, error) {
+	var (
+		parts    []string
+		depth    int
+		inString bool
+		escaped  bool
+		start    int
+	)
+	for i, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && r == '\\':
+			escaped = true
+		case r == '"':
+			inString = !inString
+		case inString:
+		case r == '(':
+			if i > 0 && feelNamePattern.MatchString(text[i-1:i]) {
+				depth++
+			}
+		case r == ')':
+			if depth > 0 {
+				depth--
+			}
+		case r == ',' && depth == 0:
+			parts = append(parts, text[start:i])
+			start = i + 1
+		}
+	}
+	if inString {
+		return nil, errors.New("unterminated string literal")
+	}
+	if depth != 0 {
+		return nil, errors.New("unbalanced parentheses")
+	}
+	return append(parts, text[start:]), nil
+}
@@ -0,0 +1,202 @@
+package ruleengine
+
+import (
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/hit-policy"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const feeDMN = `<?xml version="1.0" encoding="UTF-8"?>
+<definitions xmlns="https://www.omg.org/spec/DMN/20191111/MODEL/" id="fees" name="Fees" namespace="http://example.com/fees">
+  <decision id="fee" name="Fee">
+    <variable name="Fee"/>
+    <decisionTable id="feeTable" hitPolicy="FIRST">
+      <input id="channelInput" label="Channel">
+        <inputExpression typeRef="string"><text>channel</text></inputExpression>
+      </input>
+      <input id="amountInput" label="Amount">
+        <inputExpression typeRef="number"><text>amount</text></inputExpression>
+      </input>
+      <output id="feeOutput" name="fee" typeRef="number"/>
+      <output id="reviewOutput" name="review" typeRef="string"/>
+      <rule id="r1">
+        <inputEntry><text>"web","app"</text></inputEntry>
+        <inputEntry><text>[0..1000)</text></inputEntry>
+        <outputEntry><text>1</text></outputEntry>
+        <outputEntry><text>"auto"</text></outputEntry>
+      </rule>
+      <rule id="r2">
+        <inputEntry><text>"web"</text></inputEntry>
+        <inputEntry><text>date("2020-01-01")</text></inputEntry>
+        <outputEntry><text>9</text></outputEntry>
+        <outputEntry><text>"manual"</text></outputEntry>
+      </rule>
+      <rule id="r3">
+        <inputEntry><text>not("branch")</text></inputEntry>
+        <inputEntry><text>&gt;= 1000</text></inputEntry>
+        <outputEntry><text>2.5</text></outputEntry>
+        <outputEntry><text>"manual"</text></outputEntry>
+      </rule>
+      <rule id="r4">
+        <inputEntry><text>-</text></inputEntry>
+        <inputEntry><text>-</text></inputEntry>
+        <outputEntry><text>0</text></outputEntry>
+        <outputEntry><text>null</text></outputEntry>
+      </rule>
+    </decisionTable>
+  </decision>
+  <decision id="discount" name="Discount">
+    <literalExpression><text>amount * 0.1</text></literalExpression>
+  </decision>
+  <decision id="total" name="Total">
+    <decisionTable hitPolicy="COLLECT" aggregation="SUM">
+      <input><inputExpression><text>amount</text></inputExpression></input>
+      <output name="total"/>
+    </decisionTable>
+  </decision>
+</definitions>`
+
+func Test_ImportDMN(t *testing.T) {
+	imported, err := ImportDMN(strings.NewReader(feeDMN))
+	if err != nil {
+		t.Fatalf("Error importing model: %v", err)
+	}
+	expectedIssues := []DMNIssue{
+		{Decision: "Fee", Rule: 2, Construct: `date("2020-01-01")`, Reason: "function calls are not supported"},
+		{Decision: "Discount", Construct: "literalExpression", Reason: "only decision tables are supported"},
+		{Decision: "Total", Construct: "COLLECT SUM", Reason: "aggregation is not supported"},
+	}
+	if !reflect.DeepEqual(imported.Unsupported, expectedIssues) {
+		t.Fatalf("Unexpected issues.\nExpected: %+v\nGot:      %+v", expectedIssues, imported.Unsupported)
+	}
+	if len(imported.Decisions) != 1 {
+		t.Fatalf("Expected 1 decision, got %d", len(imported.Decisions))
+	}
+	decision, ok := imported.Decision("Fee")
+	if !ok {
+		t.Fatalf("Decision Fee not imported")
+	}
+	expected := DecisionTable{
+		Name:      "Fee",
+		HitPolicy: hitpolicies.First,
+		Inputs:    []TableInput{{Name: "channel"}, {Name: "amount"}},
+		Outputs:   []TableOutput{{Name: "fee"}, {Name: "review"}},
+		Rows: []TableRow{
+			{
+				ID: 1,
+				Inputs: []interface{}{
+					NewCondition("", operators.In, []interface{}{"web", "app"}),
+					NewGroupCondition(logicaloperators.And,
+						NewCondition("", operators.GreaterThanEquals, float64(0)),
+						NewCondition("", operators.LessThan, float64(1000)),
+					),
+				},
+				Outputs: []interface{}{float64(1), "auto"},
+			},
+			{
+				ID: 3,
+				Inputs: []interface{}{
+					NewNotCondition(NewCondition("", operators.Equals, "branch")),
+					NewCondition("", operators.GreaterThanEquals, float64(1000)),
+				},
+				Outputs: []interface{}{2.5, "manual"},
+			},
+			{ID: 4, Inputs: []interface{}{nil, nil}, Outputs: []interface{}{float64(0), nil}},
+		},
+	}
+	if !reflect.DeepEqual(decision.Table, expected) {
+		t.Fatalf("Unexpected table.\nExpected: %+v\nGot:      %+v", expected, decision.Table)
+	}
+
+	processor, err := NewDecisionTableProcessor(decision.Table)
+	if err != nil {
+		t.Fatalf("Error compiling table: %v", err)
+	}
+	tests := []struct {
+		input    map[string]interface{}
+		expected []int
+	}{
+		{input: map[string]interface{}{"channel": "app", "amount": 999.99}, expected: []int{1}},
+		{input: map[string]interface{}{"channel": "app", "amount": 1000}, expected: []int{3}},
+		{input: map[string]interface{}{"channel": "branch", "amount": 1000}, expected: []int{4}},
+	}
+	for _, tt := range tests {
+		result, err := processor.Evaluate(tt.input)
+		if err != nil {
+			t.Fatalf("Error evaluating %v: %v", tt.input, err)
+		}
+		if !reflect.DeepEqual(result.Rows, tt.expected) {
+			t.Errorf("Input %v: expected rows %v, got %v", tt.input, tt.expected, result.Rows)
+		}
+	}
+
+	ruleSet, err := decision.Table.RuleSet()
+	if err != nil {
+		t.Fatalf("Error building rule set: %v", err)
+	}
+	rule := ruleSet.Rules[0].(Rule)
+	if rule.Condition.Conditions[0].Name != "channel" || rule.Condition.Conditions[1].Conditions[0].Name != "amount" {
+		t.Errorf("Cell conditions not bound to their columns: %+v", rule.Condition)
+	}
+}
+
+func Test_ImportDMN_Errors(t *testing.T) {
+	if _, err := ImportDMN(strings.NewReader("<definitions><decision>")); err == nil {
+		t.Errorf("Expected an error for malformed XML")
+	}
+	if _, err := ImportDMN(strings.NewReader("<model/>")); err == nil {
+		t.Errorf("Expected an error for a document that is not a DMN model")
+	}
+}
+
+func Test_parseFEELUnaryTests(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected interface{}
+		wantErr  string
+	}{
+		{text: "-", expected: nil},
+		{text: "  ", expected: nil},
+		{text: "42", expected: NewCondition("", operators.Equals, float64(42))},
+		{text: `"a\"b"`, expected: NewCondition("", operators.Equals, `a"b`)},
+		{text: "true", expected: NewCondition("", operators.Equals, true)},
+		{text: "< .5", expected: NewCondition("", operators.LessThan, 0.5)},
+		{text: `!= "x"`, expected: NewCondition("", operators.NotEquals, "x")},
+		{text: "]1..10]", expected: NewGroupCondition(logicaloperators.And,
+			NewCondition("", operators.GreaterThan, float64(1)),
+			NewCondition("", operators.LessThanEquals, float64(10)),
+		)},
+		{text: `"a,b", "c"`, expected: NewCondition("", operators.In, []interface{}{"a,b", "c"})},
+		{text: "< 0, > 100", expected: NewGroupCondition(logicaloperators.Or,
+			NewCondition("", operators.LessThan, float64(0)),
+			NewCondition("", operators.GreaterThan, float64(100)),
+		)},
+		{text: "not(1, 2)", expected: NewNotCondition(NewCondition("", operators.In, []interface{}{float64(1), float64(2)}))},
+		{text: "age", wantErr: "references to other values are not supported"},
+		{text: `< "b"`, wantErr: "only numbers can be ordered"},
+		{text: "1, -", wantErr: "- must be the only test of an entry"},
+		{text: "? > 3", wantErr: "only literals, comparisons, ranges and lists are supported"},
+		{text: `"open`, wantErr: "unterminated string literal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := parseFEELUnaryTests(tt.text)
+			if tt.wantErr != "" {
+				feelErr, ok := err.(*feelError)
+				if !ok || feelErr.reason != tt.wantErr {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
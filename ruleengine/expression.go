@@ -0,0 +1,325 @@
+package ruleengine
+
+import (
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/decimal"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const divisionScale = 16
+
+type expressionNode interface {
+	evaluate(input map[string]interface{}) (decimal.Decimal, error)
+}
+
+type numberNode struct {
+	value decimal.Decimal
+}
+
+type fieldNode struct {
+	name string
+}
+
+type unaryNode struct {
+	operand expressionNode
+}
+
+type binaryNode struct {
+	operator byte
+	left     expressionNode
+	right    expressionNode
+}
+
+type callNode struct {
+	function string
+	args     []expressionNode
+}
+
+type expressionParser struct {
+	text string
+	pos  int
+}
+
+type expressionCache struct {
+	mu          sync.RWMutex
+	expressions map[string]expressionNode
+}
+
+func newExpressionCache() *expressionCache {
+	return &expressionCache{
+		expressions: make(map[string]expressionNode),
+	}
+}
+
+func (c *expressionCache) get(text string) (expressionNode, error) {
+	c.mu.RLock()
+	node, ok := c.expressions[text]
+	c.mu.RUnlock()
+	if ok {
+		return node, nil
+	}
+	node, err := parseExpression(text)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.expressions[text] = node
+	c.mu.Unlock()
+	return node, nil
+}
+
+func parseExpression(text string) (expressionNode, error) {
+	p := &expressionParser{text: text}
+	node, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.text) {
+		return nil, p.errorf("unexpected %q", p.text[p.pos])
+	}
+	return node, nil
+}
+
+func (p *expressionParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid expression %q at position %d: %s", p.text, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *expressionParser) skipSpaces() {
+	for p.pos < len(p.text) && unicode.IsSpace(rune(p.text[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *expressionParser) peek() byte {
+	p.skipSpaces()
+	if p.pos < len(p.text) {
+		return p.text[p.pos]
+	}
+	return 0
+}
+
+func (p *expressionParser) parseSum() (expressionNode, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator := p.peek()
+		if operator != '+' && operator != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *expressionParser) parseProduct() (expressionNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator := p.peek()
+		if operator != '*' && operator != '/' && operator != '%' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *expressionParser) parseUnary() (expressionNode, error) {
+	switch p.peek() {
+	case '-':
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{operand: operand}, nil
+	case '+':
+		p.pos++
+		return p.parseUnary()
+	}
+	return p.parsePrimary()
+}
+
+func (p *expressionParser) parsePrimary() (expressionNode, error) {
+	c := p.peek()
+	switch {
+	case c == '(':
+		p.pos++
+		node, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("expected ')'")
+		}
+		p.pos++
+		return node, nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.text) && (p.text[p.pos] >= '0' && p.text[p.pos] <= '9' || p.text[p.pos] == '.') {
+			p.pos++
+		}
+		value, err := decimal.NewFromString(p.text[start:p.pos])
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		return &numberNode{value: value}, nil
+	case c == '_' || unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.text) && isIdentifierByte(p.text[p.pos]) {
+			p.pos++
+		}
+		name := p.text[start:p.pos]
+		if p.peek() != '(' {
+			return &fieldNode{name: name}, nil
+		}
+		p.pos++
+		return p.parseCall(strings.ToLower(name))
+	case c == 0:
+		return nil, p.errorf("unexpected end of expression")
+	}
+	return nil, p.errorf("unexpected %q", c)
+}
+
+func (p *expressionParser) parseCall(function string) (expressionNode, error) {
+	call := &callNode{function: function}
+	if p.peek() == ')' {
+		p.pos++
+	} else {
+		for {
+			arg, err := p.parseSum()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if p.peek() == ',' {
+				p.pos++
+				continue
+			}
+			if p.peek() != ')' {
+				return nil, p.errorf("expected ')' or ','")
+			}
+			p.pos++
+			break
+		}
+	}
+	switch function {
+	case "min", "max":
+		if len(call.args) == 0 {
+			return nil, p.errorf("%s requires at least one argument", function)
+		}
+	case "abs", "floor", "ceil":
+		if len(call.args) != 1 {
+			return nil, p.errorf("%s requires one argument", function)
+		}
+	case "round":
+		if len(call.args) != 1 && len(call.args) != 2 {
+			return nil, p.errorf("round requires one or two arguments")
+		}
+	default:
+		return nil, p.errorf("unknown function %s", function)
+	}
+	return call, nil
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '.' || c >= '0' && c <= '9' || unicode.IsLetter(rune(c))
+}
+
+func (n *numberNode) evaluate(map[string]interface{}) (decimal.Decimal, error) {
+	return n.value, nil
+}
+
+func (n *fieldNode) evaluate(input map[string]interface{}) (decimal.Decimal, error) {
+	value, ok := input[n.name]
+	if !ok {
+		return decimal.Decimal{}, fmt.Errorf("field %s is missing", n.name)
+	}
+	d, ok := toDecimal(value)
+	if !ok {
+		return decimal.Decimal{}, fmt.Errorf("field %s is not numeric: %v", n.name, value)
+	}
+	return d, nil
+}
+
+func (n *unaryNode) evaluate(input map[string]interface{}) (decimal.Decimal, error) {
+	value, err := n.operand.evaluate(input)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return value.Neg(), nil
+}
+
+func (n *binaryNode) evaluate(input map[string]interface{}) (decimal.Decimal, error) {
+	left, err := n.left.evaluate(input)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	right, err := n.right.evaluate(input)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	switch n.operator {
+	case '+':
+		return left.Add(right), nil
+	case '-':
+		return left.Sub(right), nil
+	case '*':
+		return left.Mul(right), nil
+	case '/':
+		return left.Div(right, divisionScale, decimal.HalfEven)
+	default:
+		return left.Mod(right)
+	}
+}
+
+func (n *callNode) evaluate(input map[string]interface{}) (decimal.Decimal, error) {
+	args := make([]decimal.Decimal, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.evaluate(input)
+		if err != nil {
+			return decimal.Decimal{}, err
+		}
+		args[i] = value
+	}
+	switch n.function {
+	case "min", "max":
+		result := args[0]
+		for _, arg := range args[1:] {
+			if (n.function == "min") == (arg.Cmp(result) < 0) {
+				result = arg
+			}
+		}
+		return result, nil
+	case "abs":
+		return args[0].Abs(), nil
+	case "floor":
+		return args[0].Floor(), nil
+	case "ceil":
+		return args[0].Ceil(), nil
+	default:
+		scale := int32(0)
+		if len(args) == 2 {
+			if !args[1].IsInteger() || args[1].Sign() < 0 || args[1].Cmp(decimal.NewFromInt(decimal.MaxScale)) > 0 {
+				return decimal.Decimal{}, fmt.Errorf("round scale must be an integer from 0 to %d: %s", decimal.MaxScale, args[1])
+			}
+			scale = int32(args[1].Float64())
+		}
+		return args[0].Round(scale, decimal.HalfUp), nil
+	}
+}
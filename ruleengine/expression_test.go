@@ -0,0 +1,74 @@
+package ruleengine
+
+import (
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/decimal"
+	"testing"
+)
+
+func Test_parseExpression(t *testing.T) {
+	input := map[string]interface{}{
+		"amount": 2000000,
+		"total":  "80.50",
+		"price":  19.99,
+		"qty":    3,
+	}
+	tests := []struct {
+		expression string
+		expected   string
+		wantErr    bool
+	}{
+		{expression: "min(amount * 0.015, 25000)", expected: "25000"},
+		{expression: "amount * 0.015", expected: "30000"},
+		{expression: "max(0, total - 100)", expected: "0"},
+		{expression: "price * qty", expected: "59.97"},
+		{expression: "-(price - 20) * 100", expected: "1"},
+		{expression: "round(10 / 3, 2)", expected: "3.33"},
+		{expression: "floor(price) + ceil(price) + abs(-1)", expected: "40"},
+		{expression: "qty % 2", expected: "1"},
+		{expression: "unknown + 1", wantErr: true},
+		{expression: "amount / 0", wantErr: true},
+		{expression: "round(price, 0.5)", wantErr: true},
+		{expression: "round(price, -1)", wantErr: true},
+		{expression: "round(price, 4294967298)", wantErr: true},
+		{expression: "round(price, 10001)", wantErr: true},
+		{expression: "round(price, 10000)", expected: "19.99"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			node, err := parseExpression(tt.expression)
+			if err != nil {
+				t.Fatalf("Error parsing expression: %v", err)
+			}
+			value, err := node.evaluate(input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err == nil && value.String() != tt.expected {
+				t.Errorf("Unexpected output. Expected: %s, Got: %s", tt.expected, value)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"amount *", "sqrt(amount)", "(amount", "amount $ 2", "min()"} {
+		if _, err := parseExpression(invalid); err == nil {
+			t.Errorf("Expected parse error for %q", invalid)
+		}
+	}
+}
+
+func Test_ruleEngine_ApplyCompute(t *testing.T) {
+	ruleSet := `{"rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":0}]}}],"actions":[{"type":"Compute","params":{"name":"fee","expression":"min(amount * 0.015, 25000)"}}]}`
+	result := NewRuleEngine().RegisterJsonRuleSet(ruleSet).Apply(map[string]interface{}{"amount": 100000}).GetResult()
+	fee, ok := result.Computed["fee"]
+	if !ok {
+		t.Fatalf("Expected fee output, Got: %v", result.Computed)
+	}
+	if d, ok := fee.(decimal.Decimal); !ok || d.String() != "1500" {
+		t.Errorf("Unexpected fee: %v", fee)
+	}
+
+	invalid := `{"rules":[{"id":1,"condition":{}}],"actions":[{"type":"Compute","params":{"name":"fee","expression":"amount *"}}]}`
+	if NewRuleEngine().RegisterJsonRuleSet(invalid).Err() == nil {
+		t.Errorf("Expected registration error for invalid expression")
+	}
+}
@@ -0,0 +1,11 @@
+package fieldtypes
+
+const (
+	String  = "string"
+	Number  = "number"
+	Integer = "integer"
+	Boolean = "boolean"
+	Array   = "array"
+	Object  = "object"
+	Any     = "any"
+)
@@ -0,0 +1,13 @@
+package findingkinds
+
+const (
+	Unsatisfiable = "UNSATISFIABLE"
+	Tautology     = "TAUTOLOGY"
+	DuplicateID   = "DUPLICATE_ID"
+	DuplicateRule = "DUPLICATE_RULE"
+	Subsumed      = "SUBSUMED"
+	Unreachable   = "UNREACHABLE"
+	TooComplex    = "TOO_COMPLEX"
+	Overlap       = "OVERLAP"
+	Gap           = "GAP"
+)
@@ -0,0 +1,260 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	// fragmentRefKey is the key of a reference in the rules of a rule set and in
+	// conditions, e.g. {"$ref": "sanctions.country_block"}.
+	fragmentRefKey = "$ref"
+
+	fragmentRuleSet   = "rule set"
+	fragmentRule      = "rule"
+	fragmentCondition = "condition"
+	fragmentReference = "reference"
+)
+
+// FragmentRegistry holds named rule sets, rules and conditions that rule sets
+// reference with $ref. References are resolved when a rule set is registered, so
+// a changed fragment applies to rule sets registered after the change.
+type FragmentRegistry struct {
+	mu        sync.RWMutex
+	fragments map[string]map[string]interface{}
+}
+
+func NewFragmentRegistry() *FragmentRegistry {
+	return &FragmentRegistry{
+		fragments: make(map[string]map[string]interface{}),
+	}
+}
+
+// NewFragmentRef returns a reference to a rule set or rule fragment to use as an
+// entry of RuleSet.Rules.
+func NewFragmentRef(name string) map[string]interface{} {
+	return map[string]interface{}{fragmentRefKey: name}
+}
+
+// NewRefCondition returns a reference to a condition fragment.
+func NewRefCondition(name string) Condition {
+	return Condition{Ref: name}
+}
+
+// Register stores a RuleSet, Rule or Condition, or its JSON object form, under
+// name, replacing any fragment of the same name.
+func (r *FragmentRegistry) Register(name string, fragment interface{}) error {
+	if name == "" {
+		return errors.New("fragment requires a name")
+	}
+	data, err := json.Marshal(fragment)
+	if err != nil {
+		return fmt.Errorf("fragment %s: %w", name, err)
+	}
+	return r.RegisterJson(name, string(data))
+}
+
+func (r *FragmentRegistry) RegisterJson(name string, fragmentStr string) error {
+	if name == "" {
+		return errors.New("fragment requires a name")
+	}
+	var fragment map[string]interface{}
+	if err := json.Unmarshal([]byte(fragmentStr), &fragment); err != nil {
+		return fmt.Errorf("fragment %s: %w", name, err)
+	}
+	if fragment == nil {
+		return fmt.Errorf("fragment %s must be an object", name)
+	}
+	r.mu.Lock()
+	r.fragments[name] = fragment
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *FragmentRegistry) Remove(name string) {
+	r.mu.Lock()
+	delete(r.fragments, name)
+	r.mu.Unlock()
+}
+
+func (r *FragmentRegistry) get(name string) (map[string]interface{}, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fragment, ok := r.fragments[name]
+	return fragment, ok
+}
+
+// fragmentResolver replaces references with the fragments they name, keeping
+// the chain of fragments being expanded to detect cycles.
+type fragmentResolver struct {
+	registry *FragmentRegistry
+	chain    []string
+}
+
+func (re *engine) resolveRuleSet(ruleSet RuleSet) (RuleSet, error) {
+	resolver := &fragmentResolver{registry: re.config.fragments}
+	return resolver.ruleSet(ruleSet)
+}
+
+func (f *fragmentResolver) enter(name string) (map[string]interface{}, error) {
+	for i, entered := range f.chain {
+		if entered == name {
+			cycle := append(append([]string(nil), f.chain[i:]...), name)
+			return nil, fmt.Errorf("fragment cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	fragment, ok := f.registry.get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown fragment %s", name)
+	}
+	f.chain = append(f.chain, name)
+	return fragment, nil
+}
+
+func (f *fragmentResolver) leave() {
+	f.chain = f.chain[:len(f.chain)-1]
+}
+
+func (f *fragmentResolver) ruleSet(ruleSet RuleSet) (RuleSet, error) {
+	resolved := RuleSet{LogicalOperator: ruleSet.LogicalOperator, Actions: ruleSet.Actions}
+	for _, nestedRule := range ruleSet.Rules {
+		entry, err := f.entry(nestedRule)
+		if err != nil {
+			return RuleSet{}, err
+		}
+		resolved.Rules = append(resolved.Rules, entry)
+	}
+	return resolved, nil
+}
+
+// entry resolves an entry of RuleSet.Rules, returning nested rule sets in the map
+// form the engine evaluates them from.
+func (f *fragmentResolver) entry(nestedRule interface{}) (interface{}, error) {
+	switch r := nestedRule.(type) {
+	case map[string]interface{}:
+		if ref, ok := r[fragmentRefKey]; ok {
+			name, isName := ref.(string)
+			if !isName || len(r) != 1 {
+				return nil, fmt.Errorf("invalid fragment reference %v: a reference must be the only key of its object", r)
+			}
+			fragment, err := f.enter(name)
+			if err != nil {
+				return nil, err
+			}
+			defer f.leave()
+			if fragmentKind(fragment) == fragmentCondition {
+				return nil, fmt.Errorf("fragment %s is a condition and cannot be used as a rule", name)
+			}
+			entry, err := f.entry(fragment)
+			if err != nil {
+				return nil, fmt.Errorf("fragment %s: %w", name, err)
+			}
+			return entry, nil
+		}
+		if _, ok := r["rules"]; ok {
+			nested, err := decodeMapRuleSet(r)
+			if err != nil {
+				return nil, err
+			}
+			if nested, err = f.ruleSet(nested); err != nil {
+				return nil, err
+			}
+			return ruleSetToMap(nested)
+		}
+		rule, err := decodeMapRule(r)
+		if err != nil {
+			return nil, err
+		}
+		return f.rule(rule)
+	case Rule:
+		return f.rule(r)
+	}
+	return nil, errors.New(fmt.Sprintf("invalid nested rule type: %s", reflect.TypeOf(nestedRule)))
+}
+
+func (f *fragmentResolver) rule(rule Rule) (Rule, error) {
+	condition, err := f.condition(rule.Condition)
+	if err != nil {
+		return Rule{}, fmt.Errorf("rule id #%d: %w", rule.ID, err)
+	}
+	rule.Condition = condition
+	if len(rule.Bins) > 0 {
+		bins := make([]ScoreBin, 0, len(rule.Bins))
+		for _, bin := range rule.Bins {
+			if bin.Condition, err = f.condition(bin.Condition); err != nil {
+				return Rule{}, fmt.Errorf("rule id #%d: %w", rule.ID, err)
+			}
+			bins = append(bins, bin)
+		}
+		rule.Bins = bins
+	}
+	return rule, nil
+}
+
+func (f *fragmentResolver) condition(condition Condition) (Condition, error) {
+	if condition.Ref != "" {
+		name := condition.Ref
+		condition.Ref = ""
+		if !reflect.DeepEqual(condition, Condition{}) {
+			return Condition{}, fmt.Errorf("condition referencing fragment %s cannot have other fields", name)
+		}
+		fragment, err := f.enter(name)
+		if err != nil {
+			return Condition{}, err
+		}
+		defer f.leave()
+		if kind := fragmentKind(fragment); kind != fragmentCondition && kind != fragmentReference {
+			return Condition{}, fmt.Errorf("fragment %s is a %s, not a condition", name, kind)
+		}
+		var referenced Condition
+		if err = decodeMapCondition(fragment, &referenced); err != nil {
+			return Condition{}, fmt.Errorf("fragment %s: %w", name, err)
+		}
+		resolved, err := f.condition(referenced)
+		if err != nil {
+			return Condition{}, fmt.Errorf("fragment %s: %w", name, err)
+		}
+		return resolved, nil
+	}
+	if len(condition.Conditions) > 0 {
+		conditions := make([]Condition, 0, len(condition.Conditions))
+		for _, subCondition := range condition.Conditions {
+			resolved, err := f.condition(subCondition)
+			if err != nil {
+				return Condition{}, err
+			}
+			conditions = append(conditions, resolved)
+		}
+		condition.Conditions = conditions
+	}
+	if condition.Element != nil {
+		element, err := f.condition(*condition.Element)
+		if err != nil {
+			return Condition{}, err
+		}
+		condition.Element = &element
+	}
+	return condition, nil
+}
+
+// fragmentKind tells rule sets, which have rules, and rules, which have a
+// condition, apart from conditions and references to other fragments.
+func fragmentKind(fragment map[string]interface{}) string {
+	if _, ok := fragment["rules"]; ok {
+		return fragmentRuleSet
+	}
+	if _, ok := fragment["condition"]; ok {
+		return fragmentRule
+	}
+	if _, ok := fragment[fragmentRefKey]; ok && len(fragment) == 1 {
+		return fragmentReference
+	}
+	return fragmentCondition
+}
@@ -0,0 +1,148 @@
+package ruleengine
+
+import (
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sanctionedPayment = `{
+  "logical_operator": "OR",
+  "rules": [
+    {"id": 1, "condition": {"conditions": [
+      {"name": "amount", "operator": "greater_than", "value": 1000},
+      {"$ref": "sanctions.country_block"}
+    ]}},
+    {"$ref": "sanctions.watchlist"}
+  ]
+}`
+
+func sanctionsRegistry(t *testing.T) *FragmentRegistry {
+	registry := NewFragmentRegistry()
+	err := registry.RegisterJson("sanctions.country_block", `{"name": "country", "operator": "in", "value": ["KP", "IR"]}`)
+	if err != nil {
+		t.Fatalf("Error registering fragment: %v", err)
+	}
+	err = registry.Register("sanctions.watchlist", RuleSet{
+		LogicalOperator: logicaloperators.And,
+		Rules: []interface{}{
+			Rule{ID: 2, Condition: NewGroupCondition(logicaloperators.And, NewCondition("watchlisted", operators.Equals, true))},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error registering fragment: %v", err)
+	}
+	return registry
+}
+
+func Test_RegisterRuleSet_Fragments(t *testing.T) {
+	registry := sanctionsRegistry(t)
+	processor := NewRuleEngine(WithFragments(registry)).RegisterJsonRuleSet(sanctionedPayment)
+	if err := processor.Err(); err != nil {
+		t.Fatalf("Error registering rule set: %v", err)
+	}
+	tests := []struct {
+		input    map[string]interface{}
+		expected bool
+	}{
+		{input: map[string]interface{}{"amount": 5000, "country": "IR", "watchlisted": false}, expected: true},
+		{input: map[string]interface{}{"amount": 5000, "country": "ID", "watchlisted": false}, expected: false},
+		{input: map[string]interface{}{"amount": 10, "country": "ID", "watchlisted": true}, expected: true},
+	}
+	for _, tt := range tests {
+		if result := processor.Apply(tt.input).GetResult(); result.Valid != tt.expected {
+			t.Errorf("Input %v: expected %v, got %+v", tt.input, tt.expected, result)
+		}
+	}
+
+	// A changed fragment applies to rule sets registered afterwards.
+	if err := registry.Register("sanctions.country_block", NewCondition("country", operators.In, []interface{}{"ID"})); err != nil {
+		t.Fatalf("Error registering fragment: %v", err)
+	}
+	input := map[string]interface{}{"amount": 5000, "country": "ID", "watchlisted": false}
+	if processor.Apply(input).GetResult().Valid {
+		t.Errorf("Registered rule set changed with its fragment")
+	}
+	updated := NewRuleEngine(WithFragments(registry)).RegisterJsonRuleSet(sanctionedPayment)
+	if !updated.Apply(input).GetResult().Valid {
+		t.Errorf("Rule set registered after the change does not use the new fragment")
+	}
+}
+
+func Test_RegisterRuleSet_FragmentErrors(t *testing.T) {
+	registry := sanctionsRegistry(t)
+	_ = registry.RegisterJson("a", `{"logical_operator": "OR", "conditions": [{"$ref": "b"}]}`)
+	_ = registry.RegisterJson("b", `{"$ref": "a"}`)
+	_ = registry.RegisterJson("loop", `{"rules": [{"$ref": "loop"}]}`)
+
+	tests := []struct {
+		name    string
+		ruleSet string
+		wantErr string
+	}{
+		{
+			name:    "Condition cycle",
+			ruleSet: `{"rules": [{"id": 1, "condition": {"conditions": [{"$ref": "a"}]}}]}`,
+			wantErr: "fragment cycle: a -> b -> a",
+		},
+		{
+			name:    "Rule set cycle",
+			ruleSet: `{"rules": [{"$ref": "loop"}]}`,
+			wantErr: "fragment cycle: loop -> loop",
+		},
+		{
+			name:    "Unknown fragment",
+			ruleSet: `{"rules": [{"$ref": "missing"}]}`,
+			wantErr: "unknown fragment missing",
+		},
+		{
+			name:    "Condition used as a rule",
+			ruleSet: `{"rules": [{"$ref": "sanctions.country_block"}]}`,
+			wantErr: "fragment sanctions.country_block is a condition and cannot be used as a rule",
+		},
+		{
+			name:    "Rule set used as a condition",
+			ruleSet: `{"rules": [{"id": 1, "condition": {"conditions": [{"$ref": "sanctions.watchlist"}]}}]}`,
+			wantErr: "fragment sanctions.watchlist is a rule set, not a condition",
+		},
+		{
+			name:    "Reference with other fields",
+			ruleSet: `{"rules": [{"id": 1, "condition": {"conditions": [{"$ref": "sanctions.country_block", "name": "country"}]}}]}`,
+			wantErr: "condition referencing fragment sanctions.country_block cannot have other fields",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewRuleEngine(WithFragments(registry)).RegisterJsonRuleSet(tt.ruleSet).Err()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if err := NewRuleEngine().RegisterJsonRuleSet(sanctionedPayment).Err(); err == nil {
+		t.Errorf("Expected an error for references without a registry")
+	}
+	if _, err := AnalyzeJsonRuleSet(sanctionedPayment); err == nil {
+		t.Errorf("Expected the analyzer to reject unresolved references")
+	}
+	if _, err := AnalyzeJsonRuleSet(sanctionedPayment, WithFragments(registry)); err != nil {
+		t.Errorf("Unexpected error analyzing with fragments: %v", err)
+	}
+}
+
+func Test_RuleSetMatcher_Fragments(t *testing.T) {
+	matcher := NewRuleSetMatcher(WithFragments(sanctionsRegistry(t)))
+	if err := matcher.AddJson("sanctions", sanctionedPayment); err != nil {
+		t.Fatalf("Error adding rule set: %v", err)
+	}
+	matched, err := matcher.Match(map[string]interface{}{"amount": 10, "country": "ID", "watchlisted": true})
+	if err != nil {
+		t.Fatalf("Error matching: %v", err)
+	}
+	if !reflect.DeepEqual(matched, []string{"sanctions"}) {
+		t.Errorf("Expected [sanctions], got %v", matched)
+	}
+}
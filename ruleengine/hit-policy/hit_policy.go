@@ -0,0 +1,9 @@
+package hitpolicies
+
+const (
+	Unique    = "UNIQUE"
+	First     = "FIRST"
+	Priority  = "PRIORITY"
+	Collect   = "COLLECT"
+	RuleOrder = "RULE ORDER"
+)
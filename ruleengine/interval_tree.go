@@ -0,0 +1,131 @@
+package ruleengine
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+type interval struct {
+	low  float64
+	high float64
+	id   string
+}
+
+type intervalNode struct {
+	center float64
+	byLow  []interval
+	byHigh []interval
+	left   *intervalNode
+	right  *intervalNode
+}
+
+// intervalTree rebuilds its nodes on the first stab after a change, so adding n
+// intervals costs one build instead of one per insert.
+type intervalTree struct {
+	mu        sync.Mutex
+	intervals []interval
+	root      *intervalNode
+	stale     bool
+}
+
+func newIntervalTree() *intervalTree {
+	return &intervalTree{}
+}
+
+func (t *intervalTree) insert(item interval) {
+	t.intervals = append(t.intervals, item)
+	t.stale = true
+}
+
+func (t *intervalTree) remove(id string) {
+	intervals := t.intervals[:0]
+	for _, item := range t.intervals {
+		if item.id != id {
+			intervals = append(intervals, item)
+		}
+	}
+	t.intervals = intervals
+	t.stale = true
+}
+
+func (t *intervalTree) len() int {
+	return len(t.intervals)
+}
+
+// stab may run concurrently with other stabs, but not with insert or remove.
+func (t *intervalTree) stab(point float64, visit func(id string)) {
+	node := t.build()
+	for node != nil {
+		switch {
+		case point < node.center:
+			for _, item := range node.byLow {
+				if item.low > point {
+					break
+				}
+				visit(item.id)
+			}
+			node = node.left
+		case point > node.center:
+			for _, item := range node.byHigh {
+				if item.high < point {
+					break
+				}
+				visit(item.id)
+			}
+			node = node.right
+		default:
+			for _, item := range node.byLow {
+				visit(item.id)
+			}
+			return
+		}
+	}
+}
+
+func (t *intervalTree) build() *intervalNode {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stale {
+		t.root = buildIntervalNode(t.intervals)
+		t.stale = false
+	}
+	return t.root
+}
+
+func buildIntervalNode(intervals []interval) *intervalNode {
+	if len(intervals) == 0 {
+		return nil
+	}
+	endpoints := make([]float64, 0, len(intervals)*2)
+	for _, item := range intervals {
+		if !math.IsInf(item.low, 0) {
+			endpoints = append(endpoints, item.low)
+		}
+		if !math.IsInf(item.high, 0) {
+			endpoints = append(endpoints, item.high)
+		}
+	}
+	node := &intervalNode{}
+	if len(endpoints) > 0 {
+		sort.Float64s(endpoints)
+		node.center = endpoints[len(endpoints)/2]
+	}
+	var left, right []interval
+	for _, item := range intervals {
+		switch {
+		case item.high < node.center:
+			left = append(left, item)
+		case item.low > node.center:
+			right = append(right, item)
+		default:
+			node.byLow = append(node.byLow, item)
+		}
+	}
+	node.byHigh = append([]interval(nil), node.byLow...)
+	sort.Slice(node.byLow, func(i, j int) bool { return node.byLow[i].low < node.byLow[j].low })
+	sort.Slice(node.byHigh, func(i, j int) bool { return node.byHigh[i].high > node.byHigh[j].high })
+	node.left = buildIntervalNode(left)
+	node.right = buildIntervalNode(right)
+	return node
+}
@@ -1,6 +1,10 @@
 package logicaloperators
 
 const (
-	And = "AND"
-	Or  = "OR"
+	And  = "AND"
+	Or   = "OR"
+	Not  = "NOT"
+	Nand = "NAND"
+	Nor  = "NOR"
+	Xor  = "XOR"
 )
@@ -0,0 +1,396 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"math"
+	"reflect"
+	"sync"
+)
+
+type RuleSetMatcher interface {
+	Add(id string, ruleSet RuleSet) error
+	AddJson(id string, ruleSetStr string) error
+	Remove(id string)
+	Match(input map[string]interface{}) ([]string, error)
+}
+
+type matcher struct {
+	engine     *engine
+	mu         sync.RWMutex
+	ruleSets   map[string]RuleSet
+	order      map[string]int
+	sequence   int
+	equalities map[string]map[string]map[string]struct{}
+	ranges     map[string]*intervalTree
+	unindexed  map[string]struct{}
+	fields     map[string][]string
+	patterns   map[string][]string
+}
+
+// accessPath is a predicate that must hold for a rule set to match, so a rule set
+// is only a candidate for an input when at least one of its access paths holds.
+type accessPath struct {
+	field    string
+	key      string
+	low      float64
+	high     float64
+	rangeKey bool
+}
+
+func NewRuleSetMatcher(opts ...Option) RuleSetMatcher {
+	return &matcher{
+		engine:     NewRuleEngine(opts...).(*engine),
+		ruleSets:   make(map[string]RuleSet),
+		order:      make(map[string]int),
+		equalities: make(map[string]map[string]map[string]struct{}),
+		ranges:     make(map[string]*intervalTree),
+		unindexed:  make(map[string]struct{}),
+		fields:     make(map[string][]string),
+		patterns:   make(map[string][]string),
+	}
+}
+
+func (m *matcher) AddJson(id string, ruleSetStr string) error {
+	var ruleSet RuleSet
+	err := json.Unmarshal([]byte(ruleSetStr), &ruleSet)
+	if err != nil {
+		return err
+	}
+	return m.Add(id, ruleSet)
+}
+
+func (m *matcher) Add(id string, ruleSet RuleSet) error {
+	ruleSet, err := m.engine.resolveRuleSet(ruleSet)
+	if err != nil {
+		return err
+	}
+	// Preparing on a fork leaves the shared engine untouched while Match runs.
+	re := m.engine.fork()
+	if err := re.prepareRuleSet(ruleSet); err != nil {
+		m.engine.config.regexes.release(re.patterns)
+		return err
+	}
+	// Match evaluates only the candidates of an input, so windows would miss the
+	// events of every input a rule set is not a candidate for.
+	if len(re.windows) > 0 {
+		m.engine.config.regexes.release(re.patterns)
+		return fmt.Errorf("rule set %s: window conditions cannot be used in a matcher", id)
+	}
+	paths, indexable, err := m.engine.ruleSetAccessPaths(ruleSet)
+	if err != nil {
+		m.engine.config.regexes.release(re.patterns)
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(id)
+	m.ruleSets[id] = ruleSet
+	m.patterns[id] = re.patterns
+	m.order[id] = m.sequence
+	m.sequence++
+	if !indexable {
+		m.unindexed[id] = struct{}{}
+		return nil
+	}
+	for _, path := range paths {
+		m.fields[id] = append(m.fields[id], path.field)
+		if path.rangeKey {
+			tree, ok := m.ranges[path.field]
+			if !ok {
+				tree = newIntervalTree()
+				m.ranges[path.field] = tree
+			}
+			tree.insert(interval{low: path.low, high: path.high, id: id})
+			continue
+		}
+		values, ok := m.equalities[path.field]
+		if !ok {
+			values = make(map[string]map[string]struct{})
+			m.equalities[path.field] = values
+		}
+		ids, ok := values[path.key]
+		if !ok {
+			ids = make(map[string]struct{})
+			values[path.key] = ids
+		}
+		ids[id] = struct{}{}
+	}
+	return nil
+}
+
+func (m *matcher) Remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(id)
+}
+
+func (m *matcher) remove(id string) {
+	if _, ok := m.ruleSets[id]; !ok {
+		return
+	}
+	for _, field := range m.fields[id] {
+		for key, ids := range m.equalities[field] {
+			delete(ids, id)
+			if len(ids) == 0 {
+				delete(m.equalities[field], key)
+			}
+		}
+		if tree, ok := m.ranges[field]; ok {
+			tree.remove(id)
+			if tree.len() == 0 {
+				delete(m.ranges, field)
+			}
+		}
+	}
+	m.engine.config.regexes.release(m.patterns[id])
+	delete(m.patterns, id)
+	delete(m.fields, id)
+	delete(m.unindexed, id)
+	delete(m.ruleSets, id)
+	delete(m.order, id)
+}
+
+func (m *matcher) Match(input map[string]interface{}) ([]string, error) {
+	if err := m.engine.validateInput(input); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	candidates := make(map[string]struct{})
+	for id := range m.unindexed {
+		candidates[id] = struct{}{}
+	}
+	for field, values := range m.equalities {
+		value, ok := input[field]
+		if !ok {
+			continue
+		}
+		for _, key := range m.engine.indexKeys(value) {
+			for id := range values[key] {
+				candidates[id] = struct{}{}
+			}
+		}
+	}
+	for field, tree := range m.ranges {
+		value, ok := m.engine.indexNumber(input[field])
+		if !ok {
+			continue
+		}
+		tree.stab(value, func(id string) {
+			candidates[id] = struct{}{}
+		})
+	}
+
+	matched := make([]string, 0)
+	for id := range candidates {
+		re := m.engine.fork()
+		result, err := re.applyRuleSet(input, m.ruleSets[id])
+		if err != nil {
+			return nil, fmt.Errorf("rule set %s: %w", id, err)
+		}
+		if result.Valid {
+			matched = append(matched, id)
+		}
+	}
+	sortByOrder(matched, m.order)
+	return matched, nil
+}
+
+func sortByOrder(ids []string, order map[string]int) {
+	for i := 1; i < len(ids); i++ {
+		for j := i; j > 0 && order[ids[j]] < order[ids[j-1]]; j-- {
+			ids[j], ids[j-1] = ids[j-1], ids[j]
+		}
+	}
+}
+
+func (re *engine) ruleSetAccessPaths(ruleSet RuleSet) ([]accessPath, bool, error) {
+	if ruleSet.LogicalOperator == "" {
+		ruleSet.LogicalOperator = logicaloperators.And
+	}
+	children := make([][]accessPath, 0, len(ruleSet.Rules))
+	indexable := make([]bool, 0, len(ruleSet.Rules))
+	for _, nestedRule := range ruleSet.Rules {
+		var (
+			paths []accessPath
+			ok    bool
+			err   error
+		)
+		switch r := nestedRule.(type) {
+		case map[string]interface{}:
+			if _, isRuleSet := r["rules"]; isRuleSet {
+				nested, decodeErr := decodeMapRuleSet(r)
+				if decodeErr != nil {
+					return nil, false, decodeErr
+				}
+				paths, ok, err = re.ruleSetAccessPaths(nested)
+			} else {
+				rule, decodeErr := decodeMapRule(r)
+				if decodeErr != nil {
+					return nil, false, decodeErr
+				}
+				paths, ok = re.ruleAccessPaths(rule)
+			}
+		case Rule:
+			paths, ok = re.ruleAccessPaths(r)
+		default:
+			return nil, false, errors.New(fmt.Sprintf("invalid nested rule type: %s", reflect.TypeOf(nestedRule)))
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		children = append(children, paths)
+		indexable = append(indexable, ok)
+	}
+	paths, ok := combineAccessPaths(ruleSet.LogicalOperator, children, indexable)
+	return paths, ok, nil
+}
+
+func (re *engine) ruleAccessPaths(rule Rule) ([]accessPath, bool) {
+	if rule.Condition.LogicalOperator == "" {
+		rule.Condition.LogicalOperator = logicaloperators.And
+	}
+	return re.conditionAccessPaths(rule.Condition)
+}
+
+func (re *engine) conditionAccessPaths(condition Condition) ([]accessPath, bool) {
+	if condition.LogicalOperator == logicaloperators.And || condition.LogicalOperator == logicaloperators.Or {
+		children := make([][]accessPath, 0, len(condition.Conditions))
+		indexable := make([]bool, 0, len(condition.Conditions))
+		for _, subCondition := range condition.Conditions {
+			paths, ok := re.conditionAccessPaths(subCondition)
+			children = append(children, paths)
+			indexable = append(indexable, ok)
+		}
+		return combineAccessPaths(condition.LogicalOperator, children, indexable)
+	}
+	if condition.Quantifier != "" || condition.Window != nil || isAggregateField(condition.Name) {
+		return nil, false
+	}
+
+	switch condition.Operator {
+	case operators.Equals:
+		keys := re.indexKeys(condition.Value)
+		if len(keys) == 0 {
+			return nil, false
+		}
+		paths := make([]accessPath, 0, len(keys))
+		for _, key := range keys {
+			paths = append(paths, accessPath{field: condition.Name, key: key})
+		}
+		return paths, true
+	case operators.In:
+		values := reflect.ValueOf(condition.Value)
+		if values.Kind() != reflect.Slice && values.Kind() != reflect.Array {
+			return nil, false
+		}
+		paths := make([]accessPath, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			keys := re.indexKeys(values.Index(i).Interface())
+			if len(keys) == 0 {
+				return nil, false
+			}
+			for _, key := range keys {
+				paths = append(paths, accessPath{field: condition.Name, key: key})
+			}
+		}
+		return paths, true
+	case operators.GreaterThan, operators.GreaterThanEquals:
+		value, ok := re.indexNumber(condition.Value)
+		if !ok {
+			return nil, false
+		}
+		return []accessPath{{field: condition.Name, low: math.Floor(value) - re.indexSlack(), high: math.Inf(1), rangeKey: true}}, true
+	case operators.LessThan, operators.LessThanEquals:
+		value, ok := re.indexNumber(condition.Value)
+		if !ok {
+			return nil, false
+		}
+		return []accessPath{{field: condition.Name, low: math.Inf(-1), high: math.Ceil(value) + re.indexSlack(), rangeKey: true}}, true
+	}
+	return nil, false
+}
+
+// combineAccessPaths picks the most selective indexable child of an AND group,
+// preferring equality lookups, and requires every child of an OR group to be indexable.
+func combineAccessPaths(logicalOperator string, children [][]accessPath, indexable []bool) ([]accessPath, bool) {
+	switch logicalOperator {
+	case logicaloperators.And:
+		best := -1
+		for i, paths := range children {
+			if !indexable[i] {
+				continue
+			}
+			if best < 0 || accessPathCost(paths) < accessPathCost(children[best]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			return nil, false
+		}
+		return children[best], true
+	case logicaloperators.Or:
+		var union []accessPath
+		for i, paths := range children {
+			if !indexable[i] {
+				return nil, false
+			}
+			union = append(union, paths...)
+		}
+		return union, true
+	}
+	return nil, false
+}
+
+func accessPathCost(paths []accessPath) int {
+	cost := 0
+	for _, path := range paths {
+		if path.rangeKey {
+			cost += 10
+		} else {
+			cost++
+		}
+	}
+	return cost
+}
+
+// indexKeys returns every key under which a value can compare equal: strings are
+// compared as text and, when numeric, also as numbers.
+func (re *engine) indexKeys(value interface{}) []string {
+	if v, ok := value.(bool); ok {
+		return []string{fmt.Sprintf("b:%v", v)}
+	}
+	var keys []string
+	if text, ok := toText(value); ok {
+		keys = append(keys, "s:"+text)
+	}
+	if d, ok := re.toComparableDecimal(value); ok {
+		keys = append(keys, "n:"+d.String())
+	}
+	return keys
+}
+
+// indexNumber converts a value for the interval trees. Bounds are widened by
+// indexSlack since float64 conversion and precision rounding are inexact.
+func (re *engine) indexNumber(value interface{}) (float64, bool) {
+	d, ok := toDecimal(value)
+	if !ok {
+		return 0, false
+	}
+	return d.Float64(), true
+}
+
+func (re *engine) indexSlack() float64 {
+	if precision := re.config.numericPrecision; precision != nil && precision.Scale < 0 {
+		return math.Pow10(int(-precision.Scale))
+	}
+	return 1
+}
@@ -0,0 +1,156 @@
+package ruleengine
+
+import (
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/aggregate-function"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func Test_matcher_Match(t *testing.T) {
+	m := NewRuleSetMatcher()
+	ruleSets := map[string]string{
+		"merchant-a": `{"logical_operator":"OR","rules":[{"id":1,"condition":{"conditions":[{"name":"merchant_id","operator":"equals","value":"A"},{"name":"amount","operator":"greater_than","value":1000}]}}]}`,
+		"merchant-b": `{"rules":[{"id":1,"condition":{"conditions":[{"name":"merchant_id","operator":"in","value":["B","C"]}]}}]}`,
+		"large":      `{"rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than_equals","value":5000}]}}]}`,
+		"small":      `{"rules":[{"id":1,"condition":{"logical_operator":"OR","conditions":[{"name":"amount","operator":"less_than","value":10},{"name":"merchant_id","operator":"equals","value":"Z"}]}}]}`,
+		"remark":     `{"rules":[{"id":1,"condition":{"conditions":[{"name":"remark","operator":"match","value":"^BFST"}]}}]}`,
+	}
+	for _, id := range []string{"merchant-a", "merchant-b", "large", "small", "remark"} {
+		if err := m.AddJson(id, ruleSets[id]); err != nil {
+			t.Fatalf("Error adding rule set %s: %v", id, err)
+		}
+	}
+	err := m.Add("builder", RuleSet{Rules: []interface{}{
+		Rule{ID: 1, Condition: NewGroupCondition(logicaloperators.And,
+			NewCondition("merchant_id", operators.Equals, "A"),
+			NewCondition("amount", operators.LessThanEquals, 500))},
+	}})
+	if err != nil {
+		t.Fatalf("Error adding rule set: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		input    map[string]interface{}
+		expected []string
+	}{
+		{
+			name:     "Equality and range",
+			input:    map[string]interface{}{"merchant_id": "A", "amount": 6000},
+			expected: []string{"merchant-a", "large"},
+		},
+		{
+			name:     "In operator",
+			input:    map[string]interface{}{"merchant_id": "C", "amount": 100},
+			expected: []string{"merchant-b"},
+		},
+		{
+			name:     "Upper bound",
+			input:    map[string]interface{}{"merchant_id": "A", "amount": 5},
+			expected: []string{"small", "builder"},
+		},
+		{
+			name:     "Unindexed rule set",
+			input:    map[string]interface{}{"merchant_id": "X", "amount": 100, "remark": "BFST1"},
+			expected: []string{"remark"},
+		},
+		{
+			name:     "Numeric string input",
+			input:    map[string]interface{}{"merchant_id": "X", "amount": "7000"},
+			expected: []string{"large"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := m.Match(tt.input)
+			if err != nil {
+				t.Fatalf("Error matching: %v", err)
+			}
+			if !reflect.DeepEqual(output, tt.expected) {
+				t.Errorf("Unexpected output. Expected: %v, Got: %v", tt.expected, output)
+			}
+		})
+	}
+
+	m.Remove("large")
+	output, _ := m.Match(map[string]interface{}{"merchant_id": "X", "amount": 9000})
+	if len(output) != 0 {
+		t.Errorf("Unexpected output after remove. Got: %v", output)
+	}
+}
+
+func Test_intervalTree_stab(t *testing.T) {
+	tree := newIntervalTree()
+	for i := 0; i < 100; i++ {
+		tree.insert(interval{low: float64(i), high: float64(i + 10), id: string(rune('a' + i%26))})
+	}
+	for point := -5; point < 120; point++ {
+		expected := 0
+		for i := 0; i < 100; i++ {
+			if point >= i && point <= i+10 {
+				expected++
+			}
+		}
+		count := 0
+		tree.stab(float64(point), func(string) { count++ })
+		if count != expected {
+			t.Errorf("Unexpected stab count at %d. Expected: %d, Got: %d", point, expected, count)
+		}
+	}
+}
+
+func Test_intervalTree_RebuildsLazily(t *testing.T) {
+	tree := newIntervalTree()
+	tree.insert(interval{low: 0, high: 10, id: "a"})
+	tree.insert(interval{low: 5, high: 15, id: "b"})
+	if tree.root != nil {
+		t.Errorf("Expected no nodes before the first stab")
+	}
+	stabbed := func(point float64) []string {
+		var ids []string
+		tree.stab(point, func(id string) { ids = append(ids, id) })
+		sort.Strings(ids)
+		return ids
+	}
+	if ids := stabbed(7); !reflect.DeepEqual(ids, []string{"a", "b"}) {
+		t.Errorf("Expected [a b], got %v", ids)
+	}
+	tree.remove("a")
+	tree.insert(interval{low: 6, high: 8, id: "c"})
+	if ids := stabbed(7); !reflect.DeepEqual(ids, []string{"b", "c"}) {
+		t.Errorf("Expected [b c] after changes, got %v", ids)
+	}
+}
+
+func Test_matcher_Match_ExponentOutOfRange(t *testing.T) {
+	m := NewRuleSetMatcher()
+	err := m.AddJson("large", `{"rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":100}]}}]}`)
+	if err != nil {
+		t.Fatalf("Error adding rule set: %v", err)
+	}
+	for _, amount := range []string{"1e50000000", "1e-50000000"} {
+		matched, err := m.Match(map[string]interface{}{"amount": amount})
+		if err != nil || len(matched) != 0 {
+			t.Errorf("Amount %s: expected no match, got %v, error %v", amount, matched, err)
+		}
+	}
+}
+
+func Test_matcher_Add_Windows(t *testing.T) {
+	m := NewRuleSetMatcher()
+	if err := m.AddJson("large", `{"rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":100}]}}]}`); err != nil {
+		t.Fatalf("Error adding rule set: %v", err)
+	}
+	windowed := windowRuleSet(Window{Function: aggregatefunctions.Count, Key: "account", Duration: "10m"}, operators.GreaterThan, 0)
+	if err := m.Add("velocity", windowed); err == nil || !strings.Contains(err.Error(), "rule set velocity: window conditions cannot be used in a matcher") {
+		t.Errorf("Expected window conditions to be rejected, got %v", err)
+	}
+	matched, err := m.Match(map[string]interface{}{"amount": 500, "account": "A"})
+	if err != nil || !reflect.DeepEqual(matched, []string{"large"}) {
+		t.Errorf("Expected [large], got %v, error %v", matched, err)
+	}
+}
@@ -0,0 +1,7 @@
+package normalforms
+
+const (
+	None = ""
+	DNF  = "DNF"
+	CNF  = "CNF"
+)
@@ -0,0 +1,429 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/normal-form"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maxNormalFormTerms bounds DNF and CNF conversion, which can grow exponentially.
+const maxNormalFormTerms = 1024
+
+// maxXorOperands bounds the expansion of XOR groups into AND and OR groups, which
+// doubles in size with every operand.
+const maxXorOperands = 8
+
+type NormalizeOptions struct {
+	Form string
+}
+
+// NormalizeCondition flattens nested groups of the same operator and single-child
+// groups, removes duplicate predicates, folds constant groups and orders every
+// group canonically, optionally converting to DNF or CNF. The result is always a
+// group so it can be used directly as a rule condition.
+func NormalizeCondition(condition Condition, opts NormalizeOptions) (Condition, error) {
+	if condition.LogicalOperator == "" && condition.Name == "" {
+		condition.LogicalOperator = logicaloperators.And
+	}
+	normalized := simplifyCondition(condition)
+	switch opts.Form {
+	case normalforms.None:
+	case normalforms.DNF, normalforms.CNF:
+		outer, inner := logicaloperators.Or, logicaloperators.And
+		if opts.Form == normalforms.CNF {
+			outer, inner = logicaloperators.And, logicaloperators.Or
+		}
+		expanded, err := pushNegation(normalized, false)
+		if err != nil {
+			return Condition{}, err
+		}
+		converted, err := toNormalForm(simplifyCondition(expanded), outer, inner)
+		if err != nil {
+			return Condition{}, err
+		}
+		normalized = simplifyCondition(converted)
+	default:
+		return Condition{}, fmt.Errorf("unknown normal form: %s", opts.Form)
+	}
+	if !isGroup(normalized) {
+		normalized = NewGroupCondition(logicaloperators.And, normalized)
+	}
+	return normalized, nil
+}
+
+func NormalizeRuleSet(ruleSet RuleSet, opts NormalizeOptions) (RuleSet, error) {
+	normalized := RuleSet{
+		LogicalOperator: ruleSet.LogicalOperator,
+		Actions:         ruleSet.Actions,
+	}
+	for _, nestedRule := range ruleSet.Rules {
+		switch r := nestedRule.(type) {
+		case map[string]interface{}:
+			if _, ok := r["rules"]; ok {
+				nested, err := decodeMapRuleSet(r)
+				if err != nil {
+					return RuleSet{}, err
+				}
+				nested, err = NormalizeRuleSet(nested, opts)
+				if err != nil {
+					return RuleSet{}, err
+				}
+				nestedMap, err := ruleSetToMap(nested)
+				if err != nil {
+					return RuleSet{}, err
+				}
+				normalized.Rules = append(normalized.Rules, nestedMap)
+				continue
+			}
+			rule, err := decodeMapRule(r)
+			if err != nil {
+				return RuleSet{}, err
+			}
+			if rule.Condition, err = NormalizeCondition(rule.Condition, opts); err != nil {
+				return RuleSet{}, fmt.Errorf("rule id #%d: %w", rule.ID, err)
+			}
+			normalized.Rules = append(normalized.Rules, rule)
+		case Rule:
+			var err error
+			if r.Condition, err = NormalizeCondition(r.Condition, opts); err != nil {
+				return RuleSet{}, fmt.Errorf("rule id #%d: %w", r.ID, err)
+			}
+			normalized.Rules = append(normalized.Rules, r)
+		default:
+			return RuleSet{}, errors.New(fmt.Sprintf("invalid nested rule type: %s", reflect.TypeOf(nestedRule)))
+		}
+	}
+	return normalized, nil
+}
+
+// ruleSetToMap converts a nested rule set back to the map form the engine
+// evaluates nested rule sets from.
+func ruleSetToMap(ruleSet RuleSet) (map[string]interface{}, error) {
+	data, err := json.Marshal(ruleSet)
+	if err != nil {
+		return nil, err
+	}
+	var ruleMap map[string]interface{}
+	err = json.Unmarshal(data, &ruleMap)
+	return ruleMap, err
+}
+
+// EquivalentConditions reports whether two conditions have the same normal form.
+func EquivalentConditions(a, b Condition) bool {
+	x, err := NormalizeCondition(a, NormalizeOptions{})
+	if err != nil {
+		return false
+	}
+	y, err := NormalizeCondition(b, NormalizeOptions{})
+	if err != nil {
+		return false
+	}
+	return conditionKey(x) == conditionKey(y)
+}
+
+func isGroup(condition Condition) bool {
+	return condition.LogicalOperator != ""
+}
+
+// isConstant reports whether a group is an empty AND (always true) or an empty OR
+// (always false), the representations used for folded constants.
+func isConstant(condition Condition, logicalOperator string) bool {
+	return condition.LogicalOperator == logicalOperator && len(condition.Conditions) == 0
+}
+
+func simplifyCondition(condition Condition) Condition {
+	switch condition.LogicalOperator {
+	case "":
+		return simplifyPredicate(condition)
+	case logicaloperators.Not, logicaloperators.Nand:
+		return simplifyNegation(Condition{LogicalOperator: logicaloperators.And, Conditions: condition.Conditions})
+	case logicaloperators.Nor:
+		return simplifyNegation(Condition{LogicalOperator: logicaloperators.Or, Conditions: condition.Conditions})
+	case logicaloperators.Xor:
+		return simplifyXor(condition)
+	case logicaloperators.And, logicaloperators.Or:
+	default:
+		return condition
+	}
+	operator := condition.LogicalOperator
+	absorbing := logicaloperators.Or
+	if operator == logicaloperators.Or {
+		absorbing = logicaloperators.And
+	}
+
+	seen := make(map[string]bool)
+	children := make([]Condition, 0, len(condition.Conditions))
+	var add func(child Condition) bool
+	add = func(child Condition) bool {
+		if child.LogicalOperator == operator {
+			for _, grandChild := range child.Conditions {
+				if !add(grandChild) {
+					return false
+				}
+			}
+			return true
+		}
+		if isConstant(child, absorbing) {
+			return false
+		}
+		key := conditionKey(child)
+		if !seen[key] {
+			seen[key] = true
+			children = append(children, child)
+		}
+		return true
+	}
+	for _, subCondition := range condition.Conditions {
+		if !add(simplifyCondition(subCondition)) {
+			return Condition{LogicalOperator: absorbing}
+		}
+	}
+
+	if len(children) == 1 {
+		return children[0]
+	}
+	sort.SliceStable(children, func(i, j int) bool {
+		return conditionKey(children[i]) < conditionKey(children[j])
+	})
+	return Condition{LogicalOperator: operator, Conditions: children}
+}
+
+// simplifyNegation rewrites NAND and NOR as NOT over a single group, removing
+// double negations and folding negated constants.
+func simplifyNegation(condition Condition) Condition {
+	child := simplifyCondition(condition)
+	switch {
+	case child.LogicalOperator == logicaloperators.Not:
+		return child.Conditions[0]
+	case isConstant(child, logicaloperators.And):
+		return Condition{LogicalOperator: logicaloperators.Or}
+	case isConstant(child, logicaloperators.Or):
+		return Condition{LogicalOperator: logicaloperators.And}
+	}
+	return NewNotCondition(child)
+}
+
+// simplifyXor flattens nested XOR groups, cancels operands that appear twice and
+// folds constant operands, negating the group for every operand that is always true.
+func simplifyXor(condition Condition) Condition {
+	negated := false
+	counts := make(map[string]int)
+	operands := make(map[string]Condition)
+	var add func(child Condition)
+	add = func(child Condition) {
+		switch {
+		case child.LogicalOperator == logicaloperators.Xor:
+			for _, grandChild := range child.Conditions {
+				add(simplifyCondition(grandChild))
+			}
+		case isConstant(child, logicaloperators.Or):
+		case isConstant(child, logicaloperators.And):
+			negated = !negated
+		default:
+			key := conditionKey(child)
+			counts[key]++
+			operands[key] = child
+		}
+	}
+	for _, subCondition := range condition.Conditions {
+		add(simplifyCondition(subCondition))
+	}
+
+	keys := make([]string, 0, len(counts))
+	for key, count := range counts {
+		if count%2 == 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var result Condition
+	switch len(keys) {
+	case 0:
+		result = Condition{LogicalOperator: logicaloperators.Or}
+	case 1:
+		result = operands[keys[0]]
+	default:
+		result = Condition{LogicalOperator: logicaloperators.Xor}
+		for _, key := range keys {
+			result.Conditions = append(result.Conditions, operands[key])
+		}
+	}
+	if negated {
+		return simplifyNegation(result)
+	}
+	return result
+}
+
+// pushNegation rewrites NOT, NAND, NOR and XOR groups into AND and OR groups using
+// De Morgan's laws, leaving NOT only directly above a predicate. Negated
+// predicates are not replaced by their inverse operator because a missing or
+// mistyped field makes both false.
+func pushNegation(condition Condition, negate bool) (Condition, error) {
+	switch condition.LogicalOperator {
+	case "":
+		if negate {
+			return NewNotCondition(condition), nil
+		}
+		return condition, nil
+	case logicaloperators.And, logicaloperators.Or:
+		operator := condition.LogicalOperator
+		if negate {
+			operator = logicaloperators.Or
+			if condition.LogicalOperator == logicaloperators.Or {
+				operator = logicaloperators.And
+			}
+		}
+		result := Condition{LogicalOperator: operator}
+		for _, subCondition := range condition.Conditions {
+			child, err := pushNegation(subCondition, negate)
+			if err != nil {
+				return Condition{}, err
+			}
+			result.Conditions = append(result.Conditions, child)
+		}
+		return result, nil
+	case logicaloperators.Not, logicaloperators.Nand:
+		return pushNegation(Condition{LogicalOperator: logicaloperators.And, Conditions: condition.Conditions}, !negate)
+	case logicaloperators.Nor:
+		return pushNegation(Condition{LogicalOperator: logicaloperators.Or, Conditions: condition.Conditions}, !negate)
+	case logicaloperators.Xor:
+		if len(condition.Conditions) > maxXorOperands {
+			return Condition{}, fmt.Errorf("XOR group with more than %d conditions cannot be expanded", maxXorOperands)
+		}
+		if len(condition.Conditions) == 0 {
+			return pushNegation(Condition{LogicalOperator: logicaloperators.Or}, negate)
+		}
+		expanded := condition.Conditions[0]
+		for _, subCondition := range condition.Conditions[1:] {
+			expanded = NewGroupCondition(logicaloperators.Or,
+				NewGroupCondition(logicaloperators.And, expanded, NewNotCondition(subCondition)),
+				NewGroupCondition(logicaloperators.And, NewNotCondition(expanded), subCondition))
+		}
+		return pushNegation(expanded, negate)
+	}
+	return Condition{}, fmt.Errorf("unknown logical operator: %s", condition.LogicalOperator)
+}
+
+// simplifyPredicate sorts and deduplicates the values of an `in` predicate.
+func simplifyPredicate(condition Condition) Condition {
+	if condition.Element != nil {
+		element := simplifyCondition(*condition.Element)
+		condition.Element = &element
+	}
+	if condition.Operator != operators.In {
+		return condition
+	}
+	values := reflect.ValueOf(condition.Value)
+	if values.Kind() != reflect.Slice && values.Kind() != reflect.Array {
+		return condition
+	}
+	keys := make(map[string]interface{}, values.Len())
+	for i := 0; i < values.Len(); i++ {
+		value := values.Index(i).Interface()
+		keys[valueKey(value)] = value
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+	unique := make([]interface{}, 0, len(sortedKeys))
+	for _, key := range sortedKeys {
+		unique = append(unique, keys[key])
+	}
+	condition.Value = unique
+	return condition
+}
+
+// toNormalForm distributes inner groups over outer groups so the result is an
+// outer group of inner groups of predicates.
+func toNormalForm(condition Condition, outer, inner string) (Condition, error) {
+	terms, err := normalFormTerms(condition, outer, inner)
+	if err != nil {
+		return Condition{}, err
+	}
+	result := Condition{LogicalOperator: outer}
+	for _, term := range terms {
+		result.Conditions = append(result.Conditions, Condition{LogicalOperator: inner, Conditions: term})
+	}
+	return result, nil
+}
+
+func normalFormTerms(condition Condition, outer, inner string) ([][]Condition, error) {
+	switch condition.LogicalOperator {
+	case outer:
+		var terms [][]Condition
+		for _, subCondition := range condition.Conditions {
+			child, err := normalFormTerms(subCondition, outer, inner)
+			if err != nil {
+				return nil, err
+			}
+			terms = append(terms, child...)
+			if len(terms) > maxNormalFormTerms {
+				return nil, fmt.Errorf("normal form exceeds %d terms", maxNormalFormTerms)
+			}
+		}
+		return terms, nil
+	case inner:
+		terms := [][]Condition{{}}
+		for _, subCondition := range condition.Conditions {
+			child, err := normalFormTerms(subCondition, outer, inner)
+			if err != nil {
+				return nil, err
+			}
+			var product [][]Condition
+			for _, left := range terms {
+				for _, right := range child {
+					term := append(append([]Condition(nil), left...), right...)
+					product = append(product, term)
+					if len(product) > maxNormalFormTerms {
+						return nil, fmt.Errorf("normal form exceeds %d terms", maxNormalFormTerms)
+					}
+				}
+			}
+			terms = product
+		}
+		return terms, nil
+	}
+	return [][]Condition{{condition}}, nil
+}
+
+func conditionKey(condition Condition) string {
+	if condition.Quantifier != "" {
+		element := "*"
+		if condition.Element != nil {
+			element = conditionKey(*condition.Element)
+		}
+		return fmt.Sprintf("%s %s[%s] %s %s", condition.Name, condition.Quantifier, element, condition.Operator, valueKey(condition.Value))
+	}
+	if condition.Window != nil {
+		return fmt.Sprintf("%s %s %s", condition.Window.id(), condition.Operator, valueKey(condition.Value))
+	}
+	if !isGroup(condition) {
+		return fmt.Sprintf("%s %s %s", condition.Name, condition.Operator, valueKey(condition.Value))
+	}
+	keys := make([]string, 0, len(condition.Conditions))
+	for _, subCondition := range condition.Conditions {
+		keys = append(keys, conditionKey(subCondition))
+	}
+	return fmt.Sprintf("~%s(%s)", condition.LogicalOperator, strings.Join(keys, ", "))
+}
+
+func valueKey(value interface{}) string {
+	if d, ok := toDecimal(value); ok {
+		if _, isText := toText(value); !isText {
+			return d.String()
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%#v", value)
+	}
+	return string(data)
+}
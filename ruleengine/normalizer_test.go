@@ -0,0 +1,142 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/normal-form"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"reflect"
+	"testing"
+)
+
+func Test_NormalizeCondition(t *testing.T) {
+	amount := NewCondition("amount", operators.GreaterThan, 1000)
+	bca := NewCondition("bank_id", operators.Equals, "bca")
+	bni := NewCondition("bank_id", operators.Equals, "bni")
+	remark := NewCondition("remark", operators.Match, "^BFST")
+
+	tests := []struct {
+		name      string
+		condition Condition
+		opts      NormalizeOptions
+		expected  Condition
+	}{
+		{
+			name:      "Flatten single child and nested groups",
+			condition: NewGroupCondition(logicaloperators.And, NewGroupCondition(logicaloperators.And, remark, NewGroupCondition(logicaloperators.Or, amount))),
+			expected:  NewGroupCondition(logicaloperators.And, amount, remark),
+		},
+		{
+			name:      "Deduplicate predicates",
+			condition: NewGroupCondition(logicaloperators.Or, bca, bni, bca, NewGroupCondition(logicaloperators.Or, bni)),
+			expected:  NewGroupCondition(logicaloperators.Or, bca, bni),
+		},
+		{
+			name:      "Leaf is wrapped in a group",
+			condition: NewGroupCondition(logicaloperators.And, NewGroupCondition(logicaloperators.Or, bca)),
+			expected:  NewGroupCondition(logicaloperators.And, bca),
+		},
+		{
+			name:      "False child folds AND group",
+			condition: NewGroupCondition(logicaloperators.And, bca, NewGroupCondition(logicaloperators.Or)),
+			expected:  NewGroupCondition(logicaloperators.Or),
+		},
+		{
+			name:      "Sort and deduplicate in values",
+			condition: NewGroupCondition(logicaloperators.And, NewCondition("bank_id", operators.In, []interface{}{"bni", "bca", "bni"})),
+			expected:  NewGroupCondition(logicaloperators.And, NewCondition("bank_id", operators.In, []interface{}{"bca", "bni"})),
+		},
+		{
+			name:      "Double negation",
+			condition: NewNotCondition(NewNotCondition(bca)),
+			expected:  NewGroupCondition(logicaloperators.And, bca),
+		},
+		{
+			name:      "NAND is NOT over AND",
+			condition: NewGroupCondition(logicaloperators.Nand, remark, amount),
+			expected:  NewNotCondition(NewGroupCondition(logicaloperators.And, amount, remark)),
+		},
+		{
+			name:      "XOR cancels repeated operands",
+			condition: NewGroupCondition(logicaloperators.Xor, bni, bca, NewGroupCondition(logicaloperators.Xor, bni, amount)),
+			expected:  NewGroupCondition(logicaloperators.Xor, amount, bca),
+		},
+		{
+			name:      "XOR with true operand is negated",
+			condition: NewGroupCondition(logicaloperators.Xor, bca, NewGroupCondition(logicaloperators.And)),
+			expected:  NewNotCondition(bca),
+		},
+		{
+			name:      "Negation pushed into disjunctive normal form",
+			condition: NewGroupCondition(logicaloperators.Nor, amount, NewGroupCondition(logicaloperators.And, bca, remark)),
+			opts:      NormalizeOptions{Form: normalforms.DNF},
+			expected: NewGroupCondition(logicaloperators.Or,
+				NewGroupCondition(logicaloperators.And, NewNotCondition(amount), NewNotCondition(bca)),
+				NewGroupCondition(logicaloperators.And, NewNotCondition(amount), NewNotCondition(remark))),
+		},
+		{
+			name:      "Disjunctive normal form",
+			condition: NewGroupCondition(logicaloperators.And, amount, NewGroupCondition(logicaloperators.Or, bca, bni)),
+			opts:      NormalizeOptions{Form: normalforms.DNF},
+			expected: NewGroupCondition(logicaloperators.Or,
+				NewGroupCondition(logicaloperators.And, amount, bca),
+				NewGroupCondition(logicaloperators.And, amount, bni)),
+		},
+		{
+			name:      "Conjunctive normal form",
+			condition: NewGroupCondition(logicaloperators.Or, NewGroupCondition(logicaloperators.And, amount, remark), bca),
+			opts:      NormalizeOptions{Form: normalforms.CNF},
+			expected: NewGroupCondition(logicaloperators.And,
+				NewGroupCondition(logicaloperators.Or, amount, bca),
+				NewGroupCondition(logicaloperators.Or, bca, remark)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := NormalizeCondition(tt.condition, tt.opts)
+			if err != nil {
+				t.Fatalf("Error normalizing condition: %v", err)
+			}
+			if !reflect.DeepEqual(output, tt.expected) {
+				t.Errorf("Unexpected output.\nExpected: %+v\nGot:      %+v", tt.expected, output)
+			}
+		})
+	}
+}
+
+func Test_EquivalentConditions(t *testing.T) {
+	a := NewGroupCondition(logicaloperators.And,
+		NewCondition("amount", operators.GreaterThan, 1000),
+		NewGroupCondition(logicaloperators.Or, NewCondition("bank_id", operators.Equals, "bca"), NewCondition("bank_id", operators.Equals, "bni")))
+	b := NewGroupCondition(logicaloperators.And,
+		NewGroupCondition(logicaloperators.Or, NewCondition("bank_id", operators.Equals, "bni"), NewCondition("bank_id", operators.Equals, "bca")),
+		NewCondition("amount", operators.GreaterThan, 1000.0))
+	if !EquivalentConditions(a, b) {
+		t.Errorf("Expected reordered conditions to be equivalent")
+	}
+	if EquivalentConditions(a, NewGroupCondition(logicaloperators.And, NewCondition("amount", operators.GreaterThan, 1000))) {
+		t.Errorf("Expected different conditions not to be equivalent")
+	}
+}
+
+func Test_NormalizeRuleSet(t *testing.T) {
+	ruleSet := RuleSet{}
+	ruleSetStr := `{"logical_operator":"OR","rules":[{"id":1,"condition":{"logical_operator":"AND","conditions":[{"logical_operator":"AND","conditions":[{"name":"amount","operator":"greater_than","value":2000}]}]}},{"rules":[{"id":2,"condition":{"logical_operator":"OR","conditions":[{"name":"remark","operator":"equals","value":"x"},{"name":"remark","operator":"equals","value":"x"}]}}]}]}`
+	if err := json.Unmarshal([]byte(ruleSetStr), &ruleSet); err != nil {
+		t.Fatalf("Error decoding rule set: %v", err)
+	}
+	normalized, err := NormalizeRuleSet(ruleSet, NormalizeOptions{Form: normalforms.DNF})
+	if err != nil {
+		t.Fatalf("Error normalizing rule set: %v", err)
+	}
+	input := map[string]interface{}{"amount": 5000, "remark": "x"}
+	expected := NewRuleEngine().RegisterRuleSet(ruleSet).Apply(input).GetResult()
+	output := NewRuleEngine().RegisterRuleSet(normalized).Apply(input).GetResult()
+	if output.Valid != expected.Valid || output.Error != "" {
+		t.Errorf("Unexpected result after normalization. Expected: %+v, Got: %+v", expected, output)
+	}
+	rule := normalized.Rules[0].(Rule)
+	if !reflect.DeepEqual(rule.Condition, NewGroupCondition(logicaloperators.And, NewCondition("amount", operators.GreaterThan, float64(2000)))) {
+		t.Errorf("Unexpected normalized rule: %+v", rule.Condition)
+	}
+}
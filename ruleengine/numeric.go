@@ -0,0 +1,105 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/decimal"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// toDecimal normalizes every Go numeric kind, json.Number and numeric strings to
+// an exact decimal. Floats use their shortest round-trip representation.
+func toDecimal(value interface{}) (decimal.Decimal, bool) {
+	var (
+		d   decimal.Decimal
+		err error
+	)
+	switch v := value.(type) {
+	case decimal.Decimal:
+		return v, true
+	case int:
+		return decimal.NewFromInt(int64(v)), true
+	case int8:
+		return decimal.NewFromInt(int64(v)), true
+	case int16:
+		return decimal.NewFromInt(int64(v)), true
+	case int32:
+		return decimal.NewFromInt(int64(v)), true
+	case int64:
+		return decimal.NewFromInt(v), true
+	case uint:
+		return decimal.NewFromUint(uint64(v)), true
+	case uint8:
+		return decimal.NewFromUint(uint64(v)), true
+	case uint16:
+		return decimal.NewFromUint(uint64(v)), true
+	case uint32:
+		return decimal.NewFromUint(uint64(v)), true
+	case uint64:
+		return decimal.NewFromUint(v), true
+	case float32:
+		d, err = decimal.NewFromFloat32(v)
+	case float64:
+		d, err = decimal.NewFromFloat(v)
+	case json.Number:
+		d, err = decimal.NewFromString(v.String())
+	case string:
+		d, err = decimal.NewFromString(strings.TrimSpace(v))
+	case *big.Int:
+		if v == nil {
+			return decimal.Decimal{}, false
+		}
+		return decimal.NewFromBigInt(v, 0), true
+	case *big.Float:
+		if v == nil {
+			return decimal.Decimal{}, false
+		}
+		d, err = decimal.NewFromBigFloat(v)
+	default:
+		return reflectDecimal(value)
+	}
+	return d, err == nil
+}
+
+// reflectDecimal handles named types such as `type Amount int64` by their
+// underlying kind.
+func reflectDecimal(value interface{}) (decimal.Decimal, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return decimal.NewFromInt(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return decimal.NewFromUint(v.Uint()), true
+	case reflect.Float32:
+		d, err := decimal.NewFromFloat32(float32(v.Float()))
+		return d, err == nil
+	case reflect.Float64:
+		d, err := decimal.NewFromFloat(v.Float())
+		return d, err == nil
+	case reflect.String:
+		d, err := decimal.NewFromString(strings.TrimSpace(v.String()))
+		return d, err == nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			return decimal.Decimal{}, false
+		}
+		return toDecimal(v.Elem().Interface())
+	}
+	return decimal.Decimal{}, false
+}
+
+// toText returns the text of string and named string values.
+func toText(value interface{}) (string, bool) {
+	if s, ok := value.(string); ok {
+		return s, true
+	}
+	if _, ok := value.(json.Number); ok {
+		return "", false
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.String {
+		return v.String(), true
+	}
+	return "", false
+}
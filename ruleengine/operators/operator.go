@@ -8,4 +8,5 @@ const (
 	GreaterThanEquals = "greater_than_equals"
 	NotEquals         = "not_equals"
 	Match             = "match"
+	In                = "in"
 )
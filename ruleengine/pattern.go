@@ -0,0 +1,265 @@
+package ruleengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Pattern is an ordered sequence of steps that events sharing the value of the Key
+// field must match within the Within duration of the first one. The last step may
+// be Absent, in which case the pattern matches when no event matches it in time.
+type Pattern struct {
+	Name   string        `json:"name"`
+	Key    string        `json:"key,omitempty"`
+	Within string        `json:"within"`
+	Steps  []PatternStep `json:"steps"`
+}
+
+type PatternStep struct {
+	Name      string    `json:"name"`
+	Condition Condition `json:"condition"`
+	Absent    bool      `json:"absent,omitempty"`
+}
+
+type StreamEvent struct {
+	Time time.Time              `json:"time"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// PatternMatch holds the events that matched the steps of a pattern, in order.
+// TimedOut is set for absence patterns, which complete when their window ends.
+type PatternMatch struct {
+	Pattern     string        `json:"pattern"`
+	Key         interface{}   `json:"key,omitempty"`
+	Events      []StreamEvent `json:"events"`
+	StartedAt   time.Time     `json:"started_at"`
+	CompletedAt time.Time     `json:"completed_at"`
+	TimedOut    bool          `json:"timed_out,omitempty"`
+}
+
+type patternRun struct {
+	key      string
+	keyValue interface{}
+	next     int
+	events   []StreamEvent
+	deadline time.Time
+}
+
+type compiledPattern struct {
+	pattern Pattern
+	within  time.Duration
+	runs    map[string][]*patternRun
+}
+
+// PatternMatcher matches patterns against a stream of events ordered by time.
+// Timeouts are driven by event time: they fire when a later event arrives or
+// when Advance is called.
+type PatternMatcher struct {
+	mu       sync.Mutex
+	engine   *engine
+	patterns []*compiledPattern
+}
+
+func NewPatternMatcher(patterns []Pattern, opts ...Option) (*PatternMatcher, error) {
+	matcher := &PatternMatcher{
+		engine: NewRuleEngine(opts...).(*engine),
+	}
+	for _, pattern := range patterns {
+		compiled, err := matcher.compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %s: %w", pattern.Name, err)
+		}
+		matcher.patterns = append(matcher.patterns, compiled)
+	}
+	return matcher, nil
+}
+
+func (m *PatternMatcher) compile(pattern Pattern) (*compiledPattern, error) {
+	if pattern.Name == "" {
+		return nil, errors.New("pattern requires a name")
+	}
+	if len(pattern.Steps) == 0 {
+		return nil, errors.New("pattern requires at least one step")
+	}
+	within, err := time.ParseDuration(pattern.Within)
+	if err != nil {
+		return nil, fmt.Errorf("invalid within duration %q: %w", pattern.Within, err)
+	}
+	if within <= 0 {
+		return nil, fmt.Errorf("within duration %q must be positive", pattern.Within)
+	}
+	// Steps are checked as rules numbered from 1, so errors name the step position.
+	ruleSet := RuleSet{}
+	for i, step := range pattern.Steps {
+		if step.Absent && (i == 0 || i != len(pattern.Steps)-1) {
+			return nil, fmt.Errorf("step %s: only the last step after the first can be absent", step.Name)
+		}
+		ruleSet.Rules = append(ruleSet.Rules, Rule{ID: i + 1, Condition: implicitAnd(step.Condition)})
+	}
+	if err = m.engine.prepareRuleSet(ruleSet); err != nil {
+		return nil, err
+	}
+	if len(m.engine.windows) > 0 {
+		return nil, errors.New("window conditions cannot be used in pattern steps")
+	}
+	return &compiledPattern{
+		pattern: pattern,
+		within:  within,
+		runs:    make(map[string][]*patternRun),
+	}, nil
+}
+
+// Process fires the timeouts that expired before the event, then advances the
+// partial matches of the event's key and starts a new one if the event matches
+// the first step.
+func (m *PatternMatcher) Process(event StreamEvent) []PatternMatch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	matches := m.advance(event.Time)
+	for _, pattern := range m.patterns {
+		matches = append(matches, m.processPattern(pattern, event)...)
+	}
+	return matches
+}
+
+// Advance fires the timeouts that expired at now without processing an event.
+func (m *PatternMatcher) Advance(now time.Time) []PatternMatch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.advance(now)
+}
+
+// Stream processes events until the channel is closed or ctx is done.
+func (m *PatternMatcher) Stream(ctx context.Context, events <-chan StreamEvent) <-chan PatternMatch {
+	matches := make(chan PatternMatch)
+	go func() {
+		defer close(matches)
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				for _, match := range m.Process(event) {
+					select {
+					case matches <- match:
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return matches
+}
+
+func (m *PatternMatcher) advance(now time.Time) []PatternMatch {
+	var matches []PatternMatch
+	for _, pattern := range m.patterns {
+		var expired []*patternRun
+		for key, runs := range pattern.runs {
+			var active []*patternRun
+			for _, run := range runs {
+				if now.Before(run.deadline) {
+					active = append(active, run)
+				} else {
+					expired = append(expired, run)
+				}
+			}
+			if len(active) > 0 {
+				pattern.runs[key] = active
+			} else {
+				delete(pattern.runs, key)
+			}
+		}
+		sort.SliceStable(expired, func(i, j int) bool {
+			if !expired[i].deadline.Equal(expired[j].deadline) {
+				return expired[i].deadline.Before(expired[j].deadline)
+			}
+			return expired[i].key < expired[j].key
+		})
+		for _, run := range expired {
+			if pattern.pattern.Steps[run.next].Absent {
+				matches = append(matches, pattern.match(run, run.deadline, true))
+			}
+		}
+	}
+	return matches
+}
+
+func (m *PatternMatcher) processPattern(pattern *compiledPattern, event StreamEvent) []PatternMatch {
+	var keyValue interface{}
+	if pattern.pattern.Key != "" {
+		value, ok := event.Data[pattern.pattern.Key]
+		if !ok || value == nil {
+			return nil
+		}
+		keyValue = value
+	}
+	key := valueKey(keyValue)
+
+	var (
+		matches []PatternMatch
+		active  []*patternRun
+	)
+	for _, run := range pattern.runs[key] {
+		step := pattern.pattern.Steps[run.next]
+		if !m.matchesStep(step, event) {
+			active = append(active, run)
+			continue
+		}
+		if step.Absent {
+			continue
+		}
+		run.events = append(run.events, event)
+		run.next++
+		if run.next == len(pattern.pattern.Steps) {
+			matches = append(matches, pattern.match(run, event.Time, false))
+			continue
+		}
+		active = append(active, run)
+	}
+
+	if m.matchesStep(pattern.pattern.Steps[0], event) {
+		run := &patternRun{
+			key:      key,
+			keyValue: keyValue,
+			next:     1,
+			events:   []StreamEvent{event},
+			deadline: event.Time.Add(pattern.within),
+		}
+		if len(pattern.pattern.Steps) == 1 {
+			matches = append(matches, pattern.match(run, event.Time, false))
+		} else {
+			active = append(active, run)
+		}
+	}
+
+	if len(active) > 0 {
+		pattern.runs[key] = active
+	} else {
+		delete(pattern.runs, key)
+	}
+	return matches
+}
+
+func (m *PatternMatcher) matchesStep(step PatternStep, event StreamEvent) bool {
+	return m.engine.evaluateConditions(event.Data, implicitAnd(step.Condition))
+}
+
+func (p *compiledPattern) match(run *patternRun, completedAt time.Time, timedOut bool) PatternMatch {
+	return PatternMatch{
+		Pattern:     p.pattern.Name,
+		Key:         run.keyValue,
+		Events:      run.events,
+		StartedAt:   run.events[0].Time,
+		CompletedAt: completedAt,
+		TimedOut:    timedOut,
+	}
+}
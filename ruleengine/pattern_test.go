@@ -0,0 +1,157 @@
+package ruleengine
+
+import (
+	"context"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"testing"
+	"time"
+)
+
+func stepCondition(name string, operator string, value interface{}) Condition {
+	return NewGroupCondition(logicaloperators.And, NewCondition(name, operator, value))
+}
+
+func Test_PatternMatcher_Sequence(t *testing.T) {
+	pattern := Pattern{
+		Name:   "account_takeover",
+		Key:    "account",
+		Within: "1h",
+		Steps: []PatternStep{
+			{Name: "new_device_login", Condition: stepCondition("type", operators.Equals, "login_new_device")},
+			{Name: "password_change", Condition: stepCondition("type", operators.Equals, "password_change")},
+			{Name: "large_transfer", Condition: NewGroupCondition(logicaloperators.And,
+				NewCondition("type", operators.Equals, "transfer"),
+				NewCondition("amount", operators.GreaterThan, 10000))},
+		},
+	}
+	matcher, err := NewPatternMatcher([]Pattern{pattern})
+	if err != nil {
+		t.Fatalf("Error creating matcher: %v", err)
+	}
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	events := []StreamEvent{
+		{Time: start, Data: map[string]interface{}{"account": "A", "type": "login_new_device"}},
+		{Time: start.Add(5 * time.Minute), Data: map[string]interface{}{"account": "B", "type": "password_change"}},
+		{Time: start.Add(10 * time.Minute), Data: map[string]interface{}{"account": "A", "type": "password_change"}},
+		{Time: start.Add(20 * time.Minute), Data: map[string]interface{}{"account": "A", "type": "transfer", "amount": 500}},
+		{Time: start.Add(30 * time.Minute), Data: map[string]interface{}{"account": "A", "type": "transfer", "amount": 20000}},
+	}
+	var matches []PatternMatch
+	for _, event := range events {
+		matches = append(matches, matcher.Process(event)...)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("Unexpected match count. Expected: 1, Got: %d", len(matches))
+	}
+	match := matches[0]
+	if match.Pattern != "account_takeover" || match.Key != "A" || len(match.Events) != 3 || match.TimedOut {
+		t.Errorf("Unexpected match: %+v", match)
+	}
+	if !match.Events[2].Time.Equal(start.Add(30*time.Minute)) || !match.CompletedAt.Equal(start.Add(30*time.Minute)) {
+		t.Errorf("Unexpected match events: %+v", match.Events)
+	}
+
+	late := []StreamEvent{
+		{Time: start.Add(2 * time.Hour), Data: map[string]interface{}{"account": "C", "type": "login_new_device"}},
+		{Time: start.Add(2*time.Hour + 10*time.Minute), Data: map[string]interface{}{"account": "C", "type": "password_change"}},
+		{Time: start.Add(3*time.Hour + time.Minute), Data: map[string]interface{}{"account": "C", "type": "transfer", "amount": 20000}},
+	}
+	for _, event := range late {
+		if matches := matcher.Process(event); len(matches) != 0 {
+			t.Errorf("Expected sequence outside window not to match, got %+v", matches)
+		}
+	}
+}
+
+func Test_PatternMatcher_LeafStepCondition(t *testing.T) {
+	matcher, err := NewPatternMatcher([]Pattern{{
+		Name:   "login_transfer",
+		Key:    "account",
+		Within: "1h",
+		Steps: []PatternStep{
+			{Name: "login", Condition: NewCondition("type", operators.Equals, "login")},
+			{Name: "transfer", Condition: NewCondition("type", operators.Equals, "transfer")},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("Error creating matcher: %v", err)
+	}
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	events := []StreamEvent{
+		{Time: start, Data: map[string]interface{}{"account": "A", "type": "noise"}},
+		{Time: start.Add(time.Minute), Data: map[string]interface{}{"account": "A", "type": "noise2"}},
+		{Time: start.Add(2 * time.Minute), Data: map[string]interface{}{"account": "A", "type": "login"}},
+		{Time: start.Add(3 * time.Minute), Data: map[string]interface{}{"account": "A", "type": "transfer"}},
+	}
+	var matches []PatternMatch
+	for _, event := range events {
+		matches = append(matches, matcher.Process(event)...)
+	}
+	if len(matches) != 1 || !matches[0].Events[0].Time.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("Expected one match starting at the login, got %+v", matches)
+	}
+}
+
+func Test_PatternMatcher_Absence(t *testing.T) {
+	pattern := Pattern{
+		Name:   "unconfirmed_payment",
+		Key:    "payment_id",
+		Within: "5m",
+		Steps: []PatternStep{
+			{Name: "request", Condition: stepCondition("type", operators.Equals, "request")},
+			{Name: "confirmation", Condition: stepCondition("type", operators.Equals, "confirmation"), Absent: true},
+		},
+	}
+	matcher, err := NewPatternMatcher([]Pattern{pattern})
+	if err != nil {
+		t.Fatalf("Error creating matcher: %v", err)
+	}
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	inputs := make(chan StreamEvent)
+	go func() {
+		defer close(inputs)
+		inputs <- StreamEvent{Time: start, Data: map[string]interface{}{"payment_id": 1, "type": "request"}}
+		inputs <- StreamEvent{Time: start.Add(time.Minute), Data: map[string]interface{}{"payment_id": 2, "type": "request"}}
+		inputs <- StreamEvent{Time: start.Add(3 * time.Minute), Data: map[string]interface{}{"payment_id": 2, "type": "confirmation"}}
+		inputs <- StreamEvent{Time: start.Add(7 * time.Minute), Data: map[string]interface{}{"payment_id": 3, "type": "other"}}
+	}()
+	var matches []PatternMatch
+	for match := range matcher.Stream(context.Background(), inputs) {
+		matches = append(matches, match)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("Unexpected match count. Expected: 1, Got: %d", len(matches))
+	}
+	if matches[0].Key != 1 || !matches[0].TimedOut || !matches[0].CompletedAt.Equal(start.Add(5*time.Minute)) {
+		t.Errorf("Unexpected match: %+v", matches[0])
+	}
+
+	matcher.Process(StreamEvent{Time: start.Add(10 * time.Minute), Data: map[string]interface{}{"payment_id": 4, "type": "request"}})
+	if matches := matcher.Advance(start.Add(14 * time.Minute)); len(matches) != 0 {
+		t.Errorf("Expected no timeout before the window ends, got %+v", matches)
+	}
+	if matches := matcher.Advance(start.Add(15 * time.Minute)); len(matches) != 1 {
+		t.Errorf("Expected timeout when the window ends, got %+v", matches)
+	}
+}
+
+func Test_NewPatternMatcher_Invalid(t *testing.T) {
+	step := PatternStep{Name: "request", Condition: stepCondition("type", operators.Equals, "request")}
+	tests := []struct {
+		name    string
+		pattern Pattern
+	}{
+		{name: "Missing steps", pattern: Pattern{Name: "p", Within: "5m"}},
+		{name: "Invalid within", pattern: Pattern{Name: "p", Within: "later", Steps: []PatternStep{step}}},
+		{name: "Absent first step", pattern: Pattern{Name: "p", Within: "5m", Steps: []PatternStep{{Name: "x", Condition: step.Condition, Absent: true}, step}}},
+		{name: "Invalid condition", pattern: Pattern{Name: "p", Within: "5m", Steps: []PatternStep{{Name: "x", Condition: stepCondition("type", operators.Match, "(")}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPatternMatcher([]Pattern{tt.pattern}); err == nil {
+				t.Errorf("Expected pattern to be rejected")
+			}
+		})
+	}
+}
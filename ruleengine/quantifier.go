@@ -0,0 +1,80 @@
+package ruleengine
+
+import (
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/quantifier"
+	"reflect"
+)
+
+// elementField is the name a quantified element condition uses for elements that
+// are not objects, e.g. the strings of a tags array.
+const elementField = "$"
+
+// evaluateQuantifier applies the element condition to every element of an array
+// field. Fields that are missing or not arrays make the condition false, while an
+// empty array makes `all` and `none` true. An element group without a logical
+// operator combines its conditions with AND.
+func (re *engine) evaluateQuantifier(input map[string]interface{}, condition Condition) bool {
+	values := reflect.ValueOf(input[condition.Name])
+	if values.Kind() != reflect.Slice && values.Kind() != reflect.Array {
+		return false
+	}
+	matched := 0
+	for i := 0; i < values.Len(); i++ {
+		if condition.Element == nil || re.evaluateConditions(elementInput(values.Index(i).Interface()), implicitAnd(*condition.Element)) {
+			matched++
+			if condition.Quantifier == quantifiers.Any {
+				return true
+			}
+			if condition.Quantifier == quantifiers.None {
+				return false
+			}
+		} else if condition.Quantifier == quantifiers.All {
+			return false
+		}
+	}
+
+	switch condition.Quantifier {
+	case quantifiers.Any:
+		return false
+	case quantifiers.All, quantifiers.None:
+		return true
+	case quantifiers.Count:
+		return re.evaluateComparison(matched, condition.Operator, condition.Value)
+	}
+	return false
+}
+
+func elementInput(element interface{}) map[string]interface{} {
+	if fields, ok := element.(map[string]interface{}); ok {
+		return fields
+	}
+	return map[string]interface{}{elementField: element}
+}
+
+func (re *engine) prepareQuantifier(rule Rule, condition Condition) error {
+	switch condition.Quantifier {
+	case quantifiers.Any, quantifiers.All, quantifiers.None:
+	case quantifiers.Count:
+		switch condition.Operator {
+		case operators.Equals, operators.NotEquals, operators.GreaterThan, operators.GreaterThanEquals, operators.LessThan, operators.LessThanEquals:
+		default:
+			return fmt.Errorf("rule id #%d: count of %s requires a comparison operator", rule.ID, condition.Name)
+		}
+		if _, ok := toDecimal(condition.Value); !ok {
+			return fmt.Errorf("rule id #%d: count of %s must be compared with a number, got %v", rule.ID, condition.Name, condition.Value)
+		}
+	default:
+		return fmt.Errorf("rule id #%d: unknown quantifier %s", rule.ID, condition.Quantifier)
+	}
+	if condition.Element == nil {
+		return nil
+	}
+	return walkCondition(implicitAnd(*condition.Element), func(element Condition) error {
+		if element.Window != nil {
+			return fmt.Errorf("rule id #%d: window conditions cannot be used in the element of %s", rule.ID, condition.Name)
+		}
+		return re.prepareCondition(rule, element)
+	})
+}
@@ -0,0 +1,8 @@
+package quantifiers
+
+const (
+	Any   = "any"
+	All   = "all"
+	None  = "none"
+	Count = "count"
+)
@@ -0,0 +1,94 @@
+package ruleengine
+
+import (
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/quantifier"
+	"testing"
+)
+
+func Test_ruleEngine_ApplyQuantifier(t *testing.T) {
+	input := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"sku": "A1", "category": "FOOD", "price": 150},
+			map[string]interface{}{"sku": "B2", "category": "ALCOHOL", "price": 250},
+			map[string]interface{}{"sku": "C3", "category": "FOOD", "price": 50},
+		},
+		"tags":  []string{"vip", "promo"},
+		"empty": []interface{}{},
+	}
+	alcohol := NewCondition("category", operators.Equals, "ALCOHOL")
+	expensive := NewCondition("price", operators.GreaterThan, 100)
+
+	tests := []struct {
+		name      string
+		condition Condition
+		expected  bool
+	}{
+		{name: "Any", condition: NewQuantifiedCondition("items", quantifiers.Any, alcohol), expected: true},
+		{name: "All", condition: NewQuantifiedCondition("items", quantifiers.All, expensive), expected: false},
+		{name: "None", condition: NewQuantifiedCondition("items", quantifiers.None, NewCondition("category", operators.Equals, "TOBACCO")), expected: true},
+		{name: "Count at least", condition: NewCountCondition("items", expensive, operators.GreaterThanEquals, 2), expected: true},
+		{name: "Count at least 3", condition: NewCountCondition("items", expensive, operators.GreaterThanEquals, 3), expected: false},
+		{name: "Group element", condition: NewQuantifiedCondition("items", quantifiers.Any, NewGroupCondition(logicaloperators.And, alcohol, NewCondition("price", operators.LessThan, 100))), expected: false},
+		{name: "Group element without logical operator", condition: NewQuantifiedCondition("items", quantifiers.All, NewGroupCondition("", expensive)), expected: false},
+		{name: "Scalar elements", condition: NewQuantifiedCondition("tags", quantifiers.Any, NewCondition(elementField, operators.Equals, "vip")), expected: true},
+		{name: "All of empty array", condition: NewQuantifiedCondition("empty", quantifiers.All, alcohol), expected: true},
+		{name: "Missing field", condition: NewQuantifiedCondition("missing", quantifiers.None, alcohol), expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ruleSet := RuleSet{Rules: []interface{}{Rule{ID: 1, Condition: NewGroupCondition(logicaloperators.And, tt.condition)}}}
+			output := NewRuleEngine().RegisterRuleSet(ruleSet).Apply(input).GetResult()
+			if output.Error != "" {
+				t.Fatalf("Error applying rule set: %v", output.Error)
+			}
+			if output.Valid != tt.expected {
+				t.Errorf("Unexpected output. Expected: %v, Got: %v", tt.expected, output.Valid)
+			}
+		})
+	}
+}
+
+func Test_ruleEngine_RegisterQuantifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		ruleSet string
+		valid   bool
+	}{
+		{
+			name:    "Json count",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"items","quantifier":"count","element":{"name":"price","operator":"greater_than","value":100},"operator":"greater_than_equals","value":1}]}}]}`,
+			valid:   true,
+		},
+		{
+			name:    "Group element without logical operator",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"items","quantifier":"any","element":{"conditions":[{"name":"price","operator":"greater_than","value":100}]}}]}}]}`,
+			valid:   true,
+		},
+		{
+			name:    "Unknown quantifier",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"items","quantifier":"most","element":{"name":"price","operator":"greater_than","value":100}}]}}]}`,
+		},
+		{
+			name:    "Count without operator",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"items","quantifier":"count","value":1}]}}]}`,
+		},
+		{
+			name:    "Invalid element pattern",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"items","quantifier":"any","element":{"name":"sku","operator":"match","value":"("}}]}}]}`,
+		},
+	}
+	input := map[string]interface{}{"items": []interface{}{map[string]interface{}{"price": 150}}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := NewRuleEngine().RegisterJsonRuleSet(tt.ruleSet)
+			if (processor.Err() == nil) != tt.valid {
+				t.Fatalf("Unexpected registration error: %v", processor.Err())
+			}
+			if tt.valid && !processor.Apply(input).GetResult().Valid {
+				t.Errorf("Expected rule set to be valid")
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package ruleengine
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sync"
+)
+
+const defaultRegexCacheSize = 256
+
+type RegexLimits struct {
+	MaxPatternLength int
+	MaxComplexity    int
+	MaxRepeat        int
+}
+
+type regexEntry struct {
+	pattern string
+	regex   *regexp.Regexp
+}
+
+// precompiledRegex is a pattern found at registration with the number of
+// registered rule sets using it.
+type precompiledRegex struct {
+	regex *regexp.Regexp
+	refs  int
+}
+
+// regexCache keeps the patterns found at registration while a registered rule set
+// uses them and holds patterns only seen during evaluation in a bounded LRU.
+type regexCache struct {
+	mu          sync.Mutex
+	limits      RegexLimits
+	size        int
+	precompiled map[string]*precompiledRegex
+	recent      *list.List
+	entries     map[string]*list.Element
+}
+
+func newRegexCache(size int, limits RegexLimits) *regexCache {
+	if size <= 0 {
+		size = defaultRegexCacheSize
+	}
+	return &regexCache{
+		limits:      limits,
+		size:        size,
+		precompiled: make(map[string]*precompiledRegex),
+		recent:      list.New(),
+		entries:     make(map[string]*list.Element),
+	}
+}
+
+// precompile compiles pattern and keeps it until release is called for it as
+// many times as it was precompiled.
+func (c *regexCache) precompile(pattern string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.precompiled[pattern]; ok {
+		entry.refs++
+		return nil
+	}
+	regex, err := compileRegex(pattern, c.limits)
+	if err != nil {
+		return err
+	}
+	c.precompiled[pattern] = &precompiledRegex{regex: regex, refs: 1}
+	return nil
+}
+
+// release drops a reference to each pattern, removing the patterns no
+// registered rule set uses anymore.
+func (c *regexCache) release(patterns []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, pattern := range patterns {
+		entry, ok := c.precompiled[pattern]
+		if !ok {
+			continue
+		}
+		if entry.refs--; entry.refs == 0 {
+			delete(c.precompiled, pattern)
+		}
+	}
+}
+
+func (c *regexCache) get(pattern string) (*regexp.Regexp, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.precompiled[pattern]; ok {
+		return entry.regex, nil
+	}
+	if element, ok := c.entries[pattern]; ok {
+		c.recent.MoveToFront(element)
+		return element.Value.(*regexEntry).regex, nil
+	}
+	regex, err := compileRegex(pattern, c.limits)
+	if err != nil {
+		return nil, err
+	}
+	c.entries[pattern] = c.recent.PushFront(&regexEntry{pattern: pattern, regex: regex})
+	if c.recent.Len() > c.size {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.entries, oldest.Value.(*regexEntry).pattern)
+	}
+	return regex, nil
+}
+
+func compileRegex(pattern string, limits RegexLimits) (*regexp.Regexp, error) {
+	if limits.MaxPatternLength > 0 && len(pattern) > limits.MaxPatternLength {
+		return nil, fmt.Errorf("regex pattern length %d exceeds limit %d", len(pattern), limits.MaxPatternLength)
+	}
+	if limits.MaxComplexity > 0 || limits.MaxRepeat > 0 {
+		tree, err := syntax.Parse(pattern, syntax.Perl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex pattern %q: %w", pattern, err)
+		}
+		nodes, maxRepeat := regexComplexity(tree)
+		if limits.MaxComplexity > 0 && nodes > limits.MaxComplexity {
+			return nil, fmt.Errorf("regex pattern %q complexity %d exceeds limit %d", pattern, nodes, limits.MaxComplexity)
+		}
+		if limits.MaxRepeat > 0 && maxRepeat > limits.MaxRepeat {
+			return nil, fmt.Errorf("regex pattern %q repeat count %d exceeds limit %d", pattern, maxRepeat, limits.MaxRepeat)
+		}
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex pattern %q: %w", pattern, err)
+	}
+	return regex, nil
+}
+
+// regexComplexity counts the syntax nodes of a pattern and the largest repeat
+// count, multiplying nested repeats since (a{100}){100} expands to 10000 states.
+func regexComplexity(tree *syntax.Regexp) (nodes int, maxRepeat int) {
+	nodes = 1
+	subRepeat := 1
+	for _, sub := range tree.Sub {
+		n, r := regexComplexity(sub)
+		nodes += n
+		if r > subRepeat {
+			subRepeat = r
+		}
+	}
+	repeat := 1
+	if tree.Op == syntax.OpRepeat {
+		repeat = tree.Max
+		if tree.Max < 0 {
+			repeat = tree.Min
+		}
+		if repeat < 1 {
+			repeat = 1
+		}
+	}
+	return nodes, repeat * subRepeat
+}
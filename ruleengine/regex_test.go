@@ -0,0 +1,136 @@
+package ruleengine
+
+import (
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func Test_compileRegex(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		limits  RegexLimits
+		wantErr bool
+	}{
+		{
+			name:    "Valid pattern without limits",
+			pattern: "BFST([0-9]+).*",
+		},
+		{
+			name:    "Invalid pattern",
+			pattern: "BFST([0-9]+",
+			wantErr: true,
+		},
+		{
+			name:    "Pattern too long",
+			pattern: strings.Repeat("a", 20),
+			limits:  RegexLimits{MaxPatternLength: 10},
+			wantErr: true,
+		},
+		{
+			name:    "Pattern too complex",
+			pattern: "(ab|cd|ef)(gh|ij|kl)",
+			limits:  RegexLimits{MaxComplexity: 5},
+			wantErr: true,
+		},
+		{
+			name:    "Nested repeat exceeds limit",
+			pattern: "(a{100}){100}",
+			limits:  RegexLimits{MaxRepeat: 1000},
+			wantErr: true,
+		},
+		{
+			name:    "Repeat within limit",
+			pattern: "[0-9]{3,10}",
+			limits:  RegexLimits{MaxRepeat: 1000, MaxComplexity: 10, MaxPatternLength: 20},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileRegex(tt.pattern, tt.limits)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Unexpected error. Expected error: %v, Got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_regexCache_get(t *testing.T) {
+	cache := newRegexCache(2, RegexLimits{})
+	for _, pattern := range []string{"a", "b", "a", "c"} {
+		if _, err := cache.get(pattern); err != nil {
+			t.Fatalf("Error compiling %s: %v", pattern, err)
+		}
+	}
+	if _, ok := cache.entries["b"]; ok {
+		t.Errorf("Expected least recently used pattern to be evicted")
+	}
+	if _, ok := cache.entries["a"]; !ok {
+		t.Errorf("Expected recently used pattern to be kept")
+	}
+}
+
+func Test_ruleEngine_RegisterInvalidRegex(t *testing.T) {
+	ruleSet := `{"rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":1}]}}],"actions":[{"type":"ReplaceString","params":{"name":"remark","pattern":"BFST([0-9]+","replacement":"$1"}}]}`
+	processor := NewRuleEngine().RegisterJsonRuleSet(ruleSet)
+	if processor.Err() == nil {
+		t.Fatalf("Expected registration error for invalid pattern")
+	}
+	result := processor.Apply(map[string]interface{}{"amount": 5, "remark": "BFST1"}).GetResult()
+	if result.Error == "" {
+		t.Errorf("Expected result error for invalid rule set")
+	}
+
+	ruleSet = `{"rules":[{"id":1,"condition":{"conditions":[{"name":"remark","operator":"match","value":"BFST[0-9]{1,500}"}]}}]}`
+	processor = NewRuleEngine(WithRegexLimits(RegexLimits{MaxRepeat: 100})).RegisterJsonRuleSet(ruleSet)
+	if processor.Err() == nil {
+		t.Errorf("Expected registration error for pattern exceeding limits")
+	}
+}
+
+func Test_regexCache_ReleasesReplacedPatterns(t *testing.T) {
+	matchRuleSet := func(pattern string) RuleSet {
+		return RuleSet{Rules: []interface{}{Rule{ID: 1, Condition: NewGroupCondition(logicaloperators.And, NewCondition("remark", operators.Match, pattern))}}}
+	}
+	precompiled := func(cache *regexCache) []string {
+		patterns := make([]string, 0, len(cache.precompiled))
+		for pattern := range cache.precompiled {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		return patterns
+	}
+
+	re := NewRuleEngine().(*engine)
+	for _, pattern := range []string{"^A", "^B", "^C"} {
+		if err := re.RegisterRuleSet(matchRuleSet(pattern)).Err(); err != nil {
+			t.Fatalf("Error registering rule set: %v", err)
+		}
+	}
+	if patterns := precompiled(re.config.regexes); !reflect.DeepEqual(patterns, []string{"^C"}) {
+		t.Errorf("Expected only the registered pattern to be kept, got %v", patterns)
+	}
+
+	matcher := NewRuleSetMatcher().(*matcher)
+	for id, pattern := range map[string]string{"a": "^A", "b": "^A", "c": "^C"} {
+		if err := matcher.Add(id, matchRuleSet(pattern)); err != nil {
+			t.Fatalf("Error adding rule set: %v", err)
+		}
+	}
+	if err := matcher.Add("c", matchRuleSet("^D")); err != nil {
+		t.Fatalf("Error replacing rule set: %v", err)
+	}
+	matcher.Remove("a")
+	if patterns := precompiled(matcher.engine.config.regexes); !reflect.DeepEqual(patterns, []string{"^A", "^D"}) {
+		t.Errorf("Expected the patterns of the remaining rule sets, got %v", patterns)
+	}
+	matcher.Remove("b")
+	matcher.Remove("c")
+	if patterns := precompiled(matcher.engine.config.regexes); len(patterns) != 0 {
+		t.Errorf("Expected every pattern to be released, got %v", patterns)
+	}
+}
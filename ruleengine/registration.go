@@ -0,0 +1,163 @@
+package ruleengine
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/action-type"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"reflect"
+)
+
+func (re *engine) prepareRuleSet(ruleSet RuleSet) error {
+	re.windows = nil
+	err := walkRuleSet(ruleSet, func(rule Rule) error {
+		// The rule condition is evaluated as an AND group when it has no logical
+		// operator; any other group needs one.
+		return walkCondition(implicitAnd(rule.Condition), func(condition Condition) error {
+			return re.prepareCondition(rule, condition)
+		})
+	}, re.prepareAction)
+	if err != nil {
+		return err
+	}
+	return re.checkRuleSetTypes(ruleSet)
+}
+
+func (re *engine) prepareCondition(rule Rule, condition Condition) error {
+	if condition.Ref != "" {
+		return fmt.Errorf("rule id #%d: unresolved fragment reference %s", rule.ID, condition.Ref)
+	}
+	if condition.LogicalOperator != "" && !isKnownLogicalOperator(condition.LogicalOperator) {
+		return fmt.Errorf("rule id #%d: unknown logical operator %s", rule.ID, condition.LogicalOperator)
+	}
+	if condition.LogicalOperator == "" && condition.Quantifier == "" {
+		if len(condition.Conditions) > 0 {
+			return fmt.Errorf("rule id #%d: condition group requires a logical operator", rule.ID)
+		}
+		if !isKnownOperator(condition.Operator) {
+			return fmt.Errorf("rule id #%d: unknown operator %q on %s", rule.ID, condition.Operator, condition.Name)
+		}
+	}
+	if isAggregateField(condition.Name) {
+		if _, err := re.config.aggregates.get(condition.Name); err != nil {
+			return fmt.Errorf("rule id #%d: %w", rule.ID, err)
+		}
+	}
+	if condition.Window != nil {
+		return re.prepareWindow(rule, condition)
+	}
+	if condition.Quantifier != "" {
+		return re.prepareQuantifier(rule, condition)
+	}
+	if condition.Operator == operators.Match {
+		pattern, ok := condition.Value.(string)
+		if !ok {
+			return fmt.Errorf("rule id #%d: match condition on %s requires a string pattern", rule.ID, condition.Name)
+		}
+		if err := re.precompileRegex(pattern); err != nil {
+			return fmt.Errorf("rule id #%d: %w", rule.ID, err)
+		}
+	}
+	return nil
+}
+
+func (re *engine) prepareAction(action Action) error {
+	if action.Type == actiontypes.ReplaceString && action.Params != nil {
+		if err := re.precompileRegex(action.Params.Pattern); err != nil {
+			return fmt.Errorf("action %s: %w", action.Type, err)
+		}
+	}
+	if action.Type == actiontypes.Compute && action.Params != nil {
+		if action.Params.Name == "" {
+			return fmt.Errorf("action %s requires an output name", action.Type)
+		}
+		if _, err := re.config.expressions.get(action.Params.Expression); err != nil {
+			return fmt.Errorf("action %s: %w", action.Type, err)
+		}
+	}
+	if action.Type == actiontypes.Template && action.Params != nil {
+		if _, err := re.config.templates.get(action.Params.Template); err != nil {
+			return fmt.Errorf("action %s: %w", action.Type, err)
+		}
+	}
+	return nil
+}
+
+// precompileRegex precompiles a pattern of the rule set being prepared and records
+// it in re.patterns, so it can be released when the rule set is replaced.
+func (re *engine) precompileRegex(pattern string) error {
+	if err := re.config.regexes.precompile(pattern); err != nil {
+		return err
+	}
+	re.patterns = append(re.patterns, pattern)
+	return nil
+}
+
+func isKnownOperator(operator string) bool {
+	switch operator {
+	case operators.Equals, operators.NotEquals, operators.GreaterThan, operators.GreaterThanEquals, operators.LessThan, operators.LessThanEquals, operators.Match, operators.In:
+		return true
+	}
+	return false
+}
+
+func isKnownLogicalOperator(logicalOperator string) bool {
+	switch logicalOperator {
+	case logicaloperators.And, logicaloperators.Or, logicaloperators.Not, logicaloperators.Nand, logicaloperators.Nor, logicaloperators.Xor:
+		return true
+	}
+	return false
+}
+
+func walkRuleSet(ruleSet RuleSet, visitRule func(rule Rule) error, visitAction func(action Action) error) error {
+	for _, nestedRule := range ruleSet.Rules {
+		switch r := nestedRule.(type) {
+		case map[string]interface{}:
+			if ref, ok := r[fragmentRefKey]; ok {
+				return fmt.Errorf("unresolved fragment reference %v", ref)
+			}
+			if _, ok := r["rules"]; ok {
+				nested, err := decodeMapRuleSet(r)
+				if err != nil {
+					return err
+				}
+				if err = walkRuleSet(nested, visitRule, visitAction); err != nil {
+					return err
+				}
+				continue
+			}
+			rule, err := decodeMapRule(r)
+			if err != nil {
+				return err
+			}
+			if err = visitRule(rule); err != nil {
+				return err
+			}
+		case Rule:
+			if err := visitRule(r); err != nil {
+				return err
+			}
+		default:
+			return errors.New(fmt.Sprintf("invalid nested rule type: %s", reflect.TypeOf(nestedRule)))
+		}
+	}
+	for _, action := range ruleSet.Actions {
+		if err := visitAction(action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkCondition(condition Condition, visit func(condition Condition) error) error {
+	if err := visit(condition); err != nil {
+		return err
+	}
+	for _, subCondition := range condition.Conditions {
+		if err := walkCondition(subCondition, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
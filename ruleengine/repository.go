@@ -0,0 +1,323 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LatestVersion looks up the most recent version of a rule set.
+const LatestVersion = 0
+
+var ruleSetNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// RuleRepository stores named rule sets as immutable, numbered versions.
+// Implementations must be safe for concurrent use.
+type RuleRepository interface {
+	// Save stores ruleSet as the next version of name, starting at 1. A rule set
+	// that an engine rejects is not stored.
+	Save(name string, ruleSet RuleSet, metadata RuleSetMetadata) (RuleSetVersion, error)
+	// Get returns a version of name, or its latest version for LatestVersion.
+	Get(name string, version int) (RuleSetVersion, error)
+	// Versions returns every version of name, oldest first.
+	Versions(name string) ([]RuleSetVersion, error)
+	// Names returns the names of the stored rule sets, sorted.
+	Names() ([]string, error)
+}
+
+type RuleSetMetadata struct {
+	Author      string    `json:"author,omitempty"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type RuleSetVersion struct {
+	Name     string          `json:"name"`
+	Version  int             `json:"version"`
+	Metadata RuleSetMetadata `json:"metadata"`
+	RuleSet  RuleSet         `json:"rule_set"`
+}
+
+// MemoryRuleRepository validates the rule sets it saves by registering them with
+// an engine configured by its options, e.g. WithFragments for rule sets that
+// reference fragments.
+type MemoryRuleRepository struct {
+	mu       sync.RWMutex
+	versions map[string][]RuleSetVersion
+	opts     []Option
+}
+
+func NewMemoryRuleRepository(opts ...Option) *MemoryRuleRepository {
+	return &MemoryRuleRepository{
+		versions: make(map[string][]RuleSetVersion),
+		opts:     opts,
+	}
+}
+
+// Save sets the creation time to now when metadata leaves it out.
+func (r *MemoryRuleRepository) Save(name string, ruleSet RuleSet, metadata RuleSetMetadata) (RuleSetVersion, error) {
+	version, err := newRuleSetVersion(name, ruleSet, metadata, r.opts)
+	if err != nil {
+		return RuleSetVersion{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	version.Version = len(r.versions[name]) + 1
+	return version, r.add(version)
+}
+
+// newRuleSetVersion builds an unnumbered version of a rule set, failing when an
+// engine configured by opts rejects it.
+func newRuleSetVersion(name string, ruleSet RuleSet, metadata RuleSetMetadata, opts []Option) (RuleSetVersion, error) {
+	if !ruleSetNamePattern.MatchString(name) {
+		return RuleSetVersion{}, fmt.Errorf("invalid rule set name %q", name)
+	}
+	// A deep copy keeps the stored version from changing with the caller's rule set.
+	data, err := json.Marshal(ruleSet)
+	if err != nil {
+		return RuleSetVersion{}, fmt.Errorf("rule set %s: %w", name, err)
+	}
+	var stored RuleSet
+	if err = json.Unmarshal(data, &stored); err != nil {
+		return RuleSetVersion{}, fmt.Errorf("rule set %s: %w", name, err)
+	}
+	if err = NewRuleEngine(opts...).RegisterRuleSet(stored).Err(); err != nil {
+		return RuleSetVersion{}, fmt.Errorf("rule set %s: %w", name, err)
+	}
+	if metadata.CreatedAt.IsZero() {
+		metadata.CreatedAt = time.Now().UTC()
+	}
+	return RuleSetVersion{Name: name, Metadata: metadata, RuleSet: stored}, nil
+}
+
+// add stores version, failing when its number is not the next one of its name.
+func (r *MemoryRuleRepository) add(version RuleSetVersion) error {
+	if expected := len(r.versions[version.Name]) + 1; version.Version != expected {
+		return fmt.Errorf("rule set %s version %d already exists", version.Name, version.Version)
+	}
+	r.versions[version.Name] = append(r.versions[version.Name], version)
+	return nil
+}
+
+func (r *MemoryRuleRepository) Get(name string, version int) (RuleSetVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := r.versions[name]
+	if len(versions) == 0 {
+		return RuleSetVersion{}, fmt.Errorf("unknown rule set %s", name)
+	}
+	if version == LatestVersion {
+		return versions[len(versions)-1], nil
+	}
+	if version < 1 || version > len(versions) {
+		return RuleSetVersion{}, fmt.Errorf("unknown version %d of rule set %s", version, name)
+	}
+	return versions[version-1], nil
+}
+
+func (r *MemoryRuleRepository) Versions(name string) ([]RuleSetVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := r.versions[name]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("unknown rule set %s", name)
+	}
+	return append([]RuleSetVersion(nil), versions...), nil
+}
+
+func (r *MemoryRuleRepository) Names() ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.versions))
+	for name := range r.versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// FileRuleRepository is a MemoryRuleRepository that writes every version to
+// <dir>/<name>/<version>.json and loads them back when opened.
+type FileRuleRepository struct {
+	mu     sync.Mutex
+	dir    string
+	memory *MemoryRuleRepository
+}
+
+// NewFileRuleRepository validates saved rule sets with opts, like NewMemoryRuleRepository.
+func NewFileRuleRepository(dir string, opts ...Option) (*FileRuleRepository, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	repository := &FileRuleRepository{
+		dir:    dir,
+		memory: NewMemoryRuleRepository(opts...),
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && ruleSetNamePattern.MatchString(entry.Name()) {
+			if err = repository.load(entry.Name()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return repository, nil
+}
+
+// load reads the versions of name in order; a missing version is an error.
+func (r *FileRuleRepository) load(name string) error {
+	files, err := filepath.Glob(filepath.Join(r.dir, name, "*.json"))
+	if err != nil {
+		return err
+	}
+	var numbers []int
+	for _, file := range files {
+		number, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			continue
+		}
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+	for _, number := range numbers {
+		data, err := os.ReadFile(r.versionPath(name, number))
+		if err != nil {
+			return err
+		}
+		var version RuleSetVersion
+		if err = json.Unmarshal(data, &version); err != nil {
+			return fmt.Errorf("rule set %s version %d: %w", name, number, err)
+		}
+		if version.Name != name || version.Version != number {
+			return fmt.Errorf("rule set %s version %d: file holds %s version %d", name, number, version.Name, version.Version)
+		}
+		if err = r.memory.add(version); err != nil {
+			return fmt.Errorf("rule set %s: missing version %d", name, len(r.memory.versions[name])+1)
+		}
+	}
+	return nil
+}
+
+func (r *FileRuleRepository) versionPath(name string, version int) string {
+	return filepath.Join(r.dir, name, strconv.Itoa(version)+".json")
+}
+
+func (r *FileRuleRepository) Save(name string, ruleSet RuleSet, metadata RuleSetMetadata) (RuleSetVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	version, err := newRuleSetVersion(name, ruleSet, metadata, r.memory.opts)
+	if err != nil {
+		return RuleSetVersion{}, err
+	}
+	// Saves hold r.mu, so the number cannot change before the version is added.
+	r.memory.mu.RLock()
+	version.Version = len(r.memory.versions[name]) + 1
+	r.memory.mu.RUnlock()
+	if err = r.write(version); err != nil {
+		return RuleSetVersion{}, err
+	}
+	r.memory.mu.Lock()
+	defer r.memory.mu.Unlock()
+	return version, r.memory.add(version)
+}
+
+// write creates the file of a version through a temporary file, so a crash never
+// leaves a partial version, and never replaces an existing one.
+func (r *FileRuleRepository) write(version RuleSetVersion) error {
+	data, err := json.MarshalIndent(version, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Join(r.dir, version.Name), 0o700); err != nil {
+		return err
+	}
+	path := r.versionPath(version.Name, version.Version)
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	if err = os.Link(tmp, path); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("rule set %s version %d already exists", version.Name, version.Version)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *FileRuleRepository) Get(name string, version int) (RuleSetVersion, error) {
+	return r.memory.Get(name, version)
+}
+
+func (r *FileRuleRepository) Versions(name string) ([]RuleSetVersion, error) {
+	return r.memory.Versions(name)
+}
+
+func (r *FileRuleRepository) Names() ([]string, error) {
+	return r.memory.Names()
+}
+
+// RepositoryEngine applies the rule sets of a repository by name. Versions are
+// immutable, so each is registered once, with the engine options, and reused.
+type RepositoryEngine struct {
+	repository RuleRepository
+	opts       []Option
+	mu         sync.Mutex
+	processors map[string]*processor
+}
+
+func NewRepositoryEngine(repository RuleRepository, opts ...Option) *RepositoryEngine {
+	return &RepositoryEngine{
+		repository: repository,
+		opts:       opts,
+		processors: make(map[string]*processor),
+	}
+}
+
+// Apply evaluates input against the latest version of the named rule set.
+func (e *RepositoryEngine) Apply(name string, input map[string]interface{}) ResultComposer {
+	return e.ApplyVersion(name, LatestVersion, input)
+}
+
+func (e *RepositoryEngine) ApplyVersion(name string, version int, input map[string]interface{}) ResultComposer {
+	p, err := e.processor(name, version)
+	if err != nil {
+		return newRuleEngineResult(EngineResult{Error: err.Error()})
+	}
+	if p.err != nil {
+		return p.Apply(input)
+	}
+	// Each evaluation gets its own engine state, so Apply is safe for concurrent use.
+	return newRuleEngineProcessor(p.ruleEngine.fork(), nil).Apply(input)
+}
+
+func (e *RepositoryEngine) processor(name string, version int) (*processor, error) {
+	stored, err := e.repository.Get(name, version)
+	if err != nil {
+		return nil, err
+	}
+	key := stored.Name + "@" + strconv.Itoa(stored.Version)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if p, ok := e.processors[key]; ok {
+		return p, nil
+	}
+	p := NewRuleEngine(e.opts...).RegisterRuleSet(stored.RuleSet).(*processor)
+	if p.err != nil {
+		p.err = fmt.Errorf("rule set %s version %d: %w", stored.Name, stored.Version, p.err)
+	}
+	e.processors[key] = p
+	return p, nil
+}
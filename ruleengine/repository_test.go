@@ -0,0 +1,197 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func amountRuleSet(max int) RuleSet {
+	return RuleSet{
+		LogicalOperator: logicaloperators.And,
+		Rules: []interface{}{
+			Rule{ID: 1, Condition: NewGroupCondition(logicaloperators.And, NewCondition("amount", operators.LessThanEquals, max))},
+		},
+	}
+}
+
+func Test_RuleRepository(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	fileRepository, err := NewFileRuleRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error opening repository: %v", err)
+	}
+	tests := []struct {
+		name       string
+		repository RuleRepository
+	}{
+		{name: "Memory", repository: NewMemoryRuleRepository()},
+		{name: "File", repository: fileRepository},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ruleSet := amountRuleSet(1000)
+			first, err := tt.repository.Save("payment.limit", ruleSet, RuleSetMetadata{Author: "ana", Description: "initial", CreatedAt: created})
+			if err != nil {
+				t.Fatalf("Error saving: %v", err)
+			}
+			if first.Version != 1 || first.Metadata.Author != "ana" || !first.Metadata.CreatedAt.Equal(created) {
+				t.Errorf("Unexpected first version %+v", first)
+			}
+			// Changing the saved rule set does not change the stored version.
+			ruleSet.LogicalOperator = logicaloperators.Or
+			second, err := tt.repository.Save("payment.limit", amountRuleSet(5000), RuleSetMetadata{Author: "budi"})
+			if err != nil {
+				t.Fatalf("Error saving: %v", err)
+			}
+			if second.Version != 2 || second.Metadata.CreatedAt.IsZero() {
+				t.Errorf("Unexpected second version %+v", second)
+			}
+			if _, err = tt.repository.Save("fraud", amountRuleSet(1), RuleSetMetadata{}); err != nil {
+				t.Fatalf("Error saving: %v", err)
+			}
+
+			latest, err := tt.repository.Get("payment.limit", LatestVersion)
+			if err != nil || latest.Version != 2 || latest.Metadata.Author != "budi" {
+				t.Errorf("Unexpected latest version %+v, error %v", latest, err)
+			}
+			stored, err := tt.repository.Get("payment.limit", 1)
+			if err != nil || stored.RuleSet.LogicalOperator != logicaloperators.And || stored.Metadata.Description != "initial" {
+				t.Errorf("Unexpected version 1 %+v, error %v", stored, err)
+			}
+			versions, err := tt.repository.Versions("payment.limit")
+			if err != nil || len(versions) != 2 || versions[0].Version != 1 || versions[1].Version != 2 {
+				t.Errorf("Unexpected versions %+v, error %v", versions, err)
+			}
+			names, err := tt.repository.Names()
+			if err != nil || !reflect.DeepEqual(names, []string{"fraud", "payment.limit"}) {
+				t.Errorf("Unexpected names %v, error %v", names, err)
+			}
+
+			if _, err = tt.repository.Get("payment.limit", 3); err == nil || !strings.Contains(err.Error(), "unknown version 3 of rule set payment.limit") {
+				t.Errorf("Expected an unknown version error, got %v", err)
+			}
+			if _, err = tt.repository.Get("missing", LatestVersion); err == nil || !strings.Contains(err.Error(), "unknown rule set missing") {
+				t.Errorf("Expected an unknown rule set error, got %v", err)
+			}
+			if _, err = tt.repository.Save("../escape", amountRuleSet(1), RuleSetMetadata{}); err == nil {
+				t.Errorf("Expected an invalid name error")
+			}
+			invalid := RuleSet{Rules: []interface{}{Rule{ID: 1, Condition: NewCondition("amount", operators.Match, 1)}}}
+			if _, err = tt.repository.Save("payment.limit", invalid, RuleSetMetadata{}); err == nil || !strings.HasPrefix(err.Error(), "rule set payment.limit: ") {
+				t.Errorf("Expected a registration error, got %v", err)
+			}
+			// A rejected rule set takes no version number.
+			if next, err := tt.repository.Save("payment.limit", amountRuleSet(1), RuleSetMetadata{}); err != nil || next.Version != 3 {
+				t.Errorf("Expected version 3, got %+v, error %v", next, err)
+			}
+		})
+	}
+}
+
+func Test_FileRuleRepository_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	repository, err := NewFileRuleRepository(dir)
+	if err != nil {
+		t.Fatalf("Error opening repository: %v", err)
+	}
+	for _, max := range []int{1000, 5000} {
+		if _, err = repository.Save("payment.limit", amountRuleSet(max), RuleSetMetadata{Author: "ana"}); err != nil {
+			t.Fatalf("Error saving: %v", err)
+		}
+	}
+
+	reopened, err := NewFileRuleRepository(dir)
+	if err != nil {
+		t.Fatalf("Error reopening repository: %v", err)
+	}
+	for version := 1; version <= 2; version++ {
+		expected, _ := repository.Get("payment.limit", version)
+		got, err := reopened.Get("payment.limit", version)
+		if err != nil || !reflect.DeepEqual(got.Metadata, expected.Metadata) || got.Name != expected.Name {
+			t.Errorf("Version %d: expected %+v, got %+v, error %v", version, expected, got, err)
+		}
+	}
+	next, err := reopened.Save("payment.limit", amountRuleSet(9000), RuleSetMetadata{})
+	if err != nil || next.Version != 3 {
+		t.Errorf("Expected version 3, got %+v, error %v", next, err)
+	}
+
+	// A version written by another process is never replaced.
+	if _, err = repository.Save("payment.limit", amountRuleSet(1), RuleSetMetadata{}); err == nil || !strings.Contains(err.Error(), "version 3 already exists") {
+		t.Errorf("Expected an existing version error, got %v", err)
+	}
+
+	if err = os.Remove(filepath.Join(dir, "payment.limit", "2.json")); err != nil {
+		t.Fatalf("Error removing version: %v", err)
+	}
+	if _, err = NewFileRuleRepository(dir); err == nil || !strings.Contains(err.Error(), "missing version 2") {
+		t.Errorf("Expected a missing version error, got %v", err)
+	}
+}
+
+func Test_RepositoryEngine_Apply(t *testing.T) {
+	repository := NewMemoryRuleRepository()
+	if _, err := repository.Save("payment.limit", amountRuleSet(1000), RuleSetMetadata{}); err != nil {
+		t.Fatalf("Error saving: %v", err)
+	}
+	engine := NewRepositoryEngine(repository)
+	input := map[string]interface{}{"amount": 3000}
+	if engine.Apply("payment.limit", input).GetResult().Valid {
+		t.Errorf("Expected version 1 to reject %v", input)
+	}
+
+	if _, err := repository.Save("payment.limit", amountRuleSet(5000), RuleSetMetadata{}); err != nil {
+		t.Fatalf("Error saving: %v", err)
+	}
+	if !engine.Apply("payment.limit", input).GetResult().Valid {
+		t.Errorf("Expected the latest version to accept %v", input)
+	}
+	if engine.ApplyVersion("payment.limit", 1, input).GetResult().Valid {
+		t.Errorf("Expected version 1 to reject %v", input)
+	}
+
+	if result := engine.Apply("missing", input).GetResult(); result.Error != "unknown rule set missing" {
+		t.Errorf("Expected an unknown rule set error, got %+v", result)
+	}
+}
+
+func Test_RuleRepository_Fragments(t *testing.T) {
+	registry := sanctionsRegistry(t)
+	var ruleSet RuleSet
+	if err := json.Unmarshal([]byte(sanctionedPayment), &ruleSet); err != nil {
+		t.Fatalf("Error parsing rule set: %v", err)
+	}
+	if _, err := NewMemoryRuleRepository().Save("sanctions", ruleSet, RuleSetMetadata{}); err == nil || !strings.Contains(err.Error(), "sanctions.country_block") {
+		t.Errorf("Expected an unknown fragment error, got %v", err)
+	}
+
+	fileRepository, err := NewFileRuleRepository(t.TempDir(), WithFragments(registry))
+	if err != nil {
+		t.Fatalf("Error opening repository: %v", err)
+	}
+	tests := []struct {
+		name       string
+		repository RuleRepository
+	}{
+		{name: "Memory", repository: NewMemoryRuleRepository(WithFragments(registry))},
+		{name: "File", repository: fileRepository},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.repository.Save("sanctions", ruleSet, RuleSetMetadata{}); err != nil {
+				t.Fatalf("Error saving: %v", err)
+			}
+			engine := NewRepositoryEngine(tt.repository, WithFragments(registry))
+			if !engine.Apply("sanctions", map[string]interface{}{"amount": 5000, "country": "KP", "watchlisted": false}).GetResult().Valid {
+				t.Errorf("Expected the sanctioned payment to match")
+			}
+		})
+	}
+}
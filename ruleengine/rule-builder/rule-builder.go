@@ -3,6 +3,7 @@ package rulebuilder
 import (
 	"encoding/json"
 	"github.com/ahmadrezamusthafa/rule-engine/ruleengine"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/action-type"
 )
 
 type Builder struct {
@@ -45,6 +46,34 @@ func (b *Builder) RegisterAction(actionType string, name, pattern, replacement s
 	return b
 }
 
+func (b *Builder) RegisterSetValue(name string, value interface{}) *Builder {
+	return b.registerParams(actiontypes.SetValue, &ruleengine.ActionParams{Name: name, Value: value})
+}
+
+func (b *Builder) RegisterDeleteField(name string) *Builder {
+	return b.registerParams(actiontypes.DeleteField, &ruleengine.ActionParams{Name: name})
+}
+
+func (b *Builder) RegisterRename(name, target string) *Builder {
+	return b.registerParams(actiontypes.Rename, &ruleengine.ActionParams{Name: name, Target: target})
+}
+
+func (b *Builder) RegisterTemplate(name, template string) *Builder {
+	return b.registerParams(actiontypes.Template, &ruleengine.ActionParams{Name: name, Template: template})
+}
+
+func (b *Builder) RegisterCompute(name, expression string) *Builder {
+	return b.registerParams(actiontypes.Compute, &ruleengine.ActionParams{Name: name, Expression: expression})
+}
+
+func (b *Builder) registerParams(actionType string, params *ruleengine.ActionParams) *Builder {
+	b.ruleSet.Actions = append(b.ruleSet.Actions, ruleengine.Action{
+		Type:   actionType,
+		Params: params,
+	})
+	return b
+}
+
 func (b *Builder) Build() ruleengine.RuleSet {
 	return b.ruleSet
 }
@@ -1,6 +1,11 @@
 package ruleengine
 
+// Rule fields Weight, Bins and ReasonCode are only used by scorecards: a matched
+// rule adds its weight, or the score of its first bin whose condition holds.
 type Rule struct {
-	ID        int       `json:"id"`
-	Condition Condition `json:"condition"`
+	ID         int        `json:"id"`
+	Condition  Condition  `json:"condition"`
+	Weight     float64    `json:"weight,omitempty"`
+	Bins       []ScoreBin `json:"bins,omitempty"`
+	ReasonCode string     `json:"reason_code,omitempty"`
 }
@@ -2,17 +2,17 @@ package ruleengine
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/action-error-policy"
 	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/action-type"
 	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
 	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
 	"github.com/mitchellh/mapstructure"
 	"log"
 	"reflect"
-	"regexp"
-	"strconv"
 )
 
 type RuleEngine interface {
@@ -24,7 +24,10 @@ type RuleEngine interface {
 }
 
 type Processor interface {
+	Err() error
 	Apply(input map[string]interface{}) ResultComposer
+	ApplyBatch(ctx context.Context, inputs []map[string]interface{}, opts BatchOptions) (BatchResult, error)
+	ApplyStream(ctx context.Context, inputs <-chan map[string]interface{}, opts BatchOptions) <-chan BatchItem
 }
 
 type ResultComposer interface {
@@ -32,29 +35,38 @@ type ResultComposer interface {
 }
 
 type engine struct {
-	ruleSet     *RuleSet
-	descBuffer  bytes.Buffer
-	ruleResults map[string]interface{}
+	ruleSet      *RuleSet
+	descBuffer   bytes.Buffer
+	ruleResults  map[string]interface{}
+	matchedRules []string
+	ruleObserver func(id string, result interface{})
+	windows      []Window
+	windowValues map[string]interface{}
+	patterns     []string
+	config       *engineConfig
 }
 
 type processor struct {
 	ruleEngine *engine
+	err        error
 }
 
 type resultComposer struct {
 	engineResult EngineResult
 }
 
-func NewRuleEngine() RuleEngine {
+func NewRuleEngine(opts ...Option) RuleEngine {
 	return &engine{
 		descBuffer:  bytes.Buffer{},
 		ruleResults: make(map[string]interface{}),
+		config:      newEngineConfig(opts...),
 	}
 }
 
-func newRuleEngineProcessor(ruleEngine *engine) Processor {
+func newRuleEngineProcessor(ruleEngine *engine, err error) Processor {
 	return &processor{
 		ruleEngine: ruleEngine,
+		err:        err,
 	}
 }
 
@@ -68,19 +80,35 @@ func (re *engine) RegisterJsonRuleSet(ruleSetStr string) Processor {
 	var ruleSet RuleSet
 	err := json.Unmarshal([]byte(ruleSetStr), &ruleSet)
 	if err != nil {
-		return nil
+		return newRuleEngineProcessor(re, err)
 	}
-	re.ruleSet = &ruleSet
-	return newRuleEngineProcessor(re)
+	return re.RegisterRuleSet(ruleSet)
 }
 
 func (re *engine) RegisterRuleSet(ruleSet RuleSet) Processor {
-	re.ruleSet = &ruleSet
-	return newRuleEngineProcessor(re)
+	// The patterns of the replaced rule set are released once the new one is
+	// prepared, so the patterns both use stay compiled.
+	previous := re.patterns
+	re.patterns = nil
+	defer re.config.regexes.release(previous)
+	resolved, err := re.resolveRuleSet(ruleSet)
+	if err != nil {
+		re.ruleSet = &ruleSet
+		return newRuleEngineProcessor(re, err)
+	}
+	re.ruleSet = &resolved
+	return newRuleEngineProcessor(re, re.prepareRuleSet(resolved))
+}
+
+func (p *processor) Err() error {
+	return p.err
 }
 
 func (p *processor) Apply(input map[string]interface{}) ResultComposer {
-	result, err := p.ruleEngine.applyRuleSet(input, *p.ruleEngine.ruleSet)
+	if p.err != nil {
+		return newRuleEngineResult(EngineResult{Error: p.err.Error()})
+	}
+	result, err := p.ruleEngine.evaluate(input, *p.ruleEngine.ruleSet)
 	if err != nil {
 		result.Error = err.Error()
 	}
@@ -91,28 +119,18 @@ func (p *resultComposer) GetResult() EngineResult {
 	return p.engineResult
 }
 
-func (re *engine) applyRuleSet(input map[string]interface{}, ruleSet RuleSet) (engineResult EngineResult, err error) {
-	if ruleSet.LogicalOperator == "" {
-		ruleSet.LogicalOperator = logicaloperators.And
+func (re *engine) evaluate(input map[string]interface{}, ruleSet RuleSet) (EngineResult, error) {
+	if err := re.validateInput(input); err != nil {
+		return EngineResult{}, err
 	}
-
-	var (
-		result           interface{}
-		validationResult bool
-	)
-
-	if ruleSet.LogicalOperator == logicaloperators.And {
-		result, err = applyLogicalAnd(input, ruleSet.Rules, re)
-	} else if ruleSet.LogicalOperator == logicaloperators.Or {
-		result, err = applyLogicalOr(input, ruleSet.Rules, re)
+	if err := re.recordWindows(input); err != nil {
+		return EngineResult{}, err
 	}
+	return re.applyRuleSet(input, ruleSet)
+}
 
-	if result == true {
-		validationResult = true
-		for _, action := range ruleSet.Actions {
-			result = applyAction(input, action)
-		}
-	}
+func (re *engine) applyRuleSet(input map[string]interface{}, ruleSet RuleSet) (engineResult EngineResult, err error) {
+	validationResult, err := re.evaluateRuleSet(input, ruleSet)
 
 	engineResult = EngineResult{
 		Valid:   validationResult,
@@ -122,29 +140,91 @@ func (re *engine) applyRuleSet(input map[string]interface{}, ruleSet RuleSet) (e
 		},
 	}
 	if validationResult && len(ruleSet.Actions) > 0 {
+		facts := input
+		if re.config.actionChaining {
+			facts = cloneInput(input)
+		}
 		actionResults := make([]ActionResult, 0)
 		for _, action := range ruleSet.Actions {
-			actionResult := applyAction(input, action)
+			actionResult, actionErr := re.applyAction(facts, action)
 			actionResults = append(actionResults, ActionResult{
 				Params: action.Params,
 				Result: actionResult,
 				Type:   action.Type,
 			})
+			if actionErr == nil {
+				if action.Type == actiontypes.Compute {
+					if engineResult.Computed == nil {
+						engineResult.Computed = make(map[string]interface{})
+					}
+					engineResult.Computed[action.Params.Name] = actionResult
+				}
+				continue
+			}
+			actionResults[len(actionResults)-1].Error = actionErr.Error()
+			if re.config.actionErrorPolicy == actionerrorpolicies.StopActions {
+				break
+			}
+			if re.config.actionErrorPolicy == actionerrorpolicies.FailResult {
+				if err == nil {
+					err = fmt.Errorf("action %s failed: %w", action.Type, actionErr)
+				}
+				break
+			}
 		}
 		engineResult.Actions = actionResults
+		if re.config.actionChaining {
+			engineResult.Output = facts
+		}
 	}
 	re.ruleResults = map[string]interface{}{}
+	re.matchedRules = nil
 	re.descBuffer.Reset()
 
 	return engineResult, err
 }
 
+// evaluateRuleSet combines the results of the rules of a rule set without running
+// its actions, so nested rule sets share the description and rule results of the
+// evaluation they are part of.
+func (re *engine) evaluateRuleSet(input map[string]interface{}, ruleSet RuleSet) (bool, error) {
+	if ruleSet.LogicalOperator == "" {
+		ruleSet.LogicalOperator = logicaloperators.And
+	}
+
+	var (
+		result bool
+		err    error
+	)
+
+	switch ruleSet.LogicalOperator {
+	case logicaloperators.And:
+		result, err = applyLogicalAnd(input, ruleSet.Rules, re)
+	case logicaloperators.Or:
+		result, err = applyLogicalOr(input, ruleSet.Rules, re)
+	case logicaloperators.Not, logicaloperators.Nand:
+		result, err = applyLogicalNot(applyLogicalAnd(input, ruleSet.Rules, re))
+	case logicaloperators.Nor:
+		result, err = applyLogicalNot(applyLogicalOr(input, ruleSet.Rules, re))
+	case logicaloperators.Xor:
+		result, err = applyLogicalXor(input, ruleSet.Rules, re)
+	}
+
+	if ruleSet.LogicalOperator != logicaloperators.And && ruleSet.LogicalOperator != logicaloperators.Or {
+		if re.descBuffer.Len() > 0 {
+			re.descBuffer.WriteRune(' ')
+		}
+		re.descBuffer.WriteString(fmt.Sprintf("Rule set %s result is %v.", ruleSet.LogicalOperator, result))
+	}
+	return result, err
+}
+
 func (re *engine) applyRule(input map[string]interface{}, rule Rule) (result interface{}, err error) {
 	if rule.Condition.LogicalOperator == "" {
 		rule.Condition.LogicalOperator = logicaloperators.And
 	}
 
-	result = evaluateConditions(input, rule.Condition)
+	result = re.evaluateConditions(input, rule.Condition)
 
 	id := fmt.Sprint(rule.ID)
 	if _, ok := re.ruleResults[id]; !ok {
@@ -153,6 +233,12 @@ func (re *engine) applyRule(input map[string]interface{}, rule Rule) (result int
 			re.descBuffer.WriteRune(' ')
 		}
 		re.descBuffer.WriteString(fmt.Sprintf("Rule id #%s result is %v.", id, result))
+		if result == true {
+			re.matchedRules = append(re.matchedRules, id)
+		}
+		if re.ruleObserver != nil {
+			re.ruleObserver(id, result)
+		}
 	}
 
 	return
@@ -236,6 +322,53 @@ func applyLogicalOr(input map[string]interface{}, rules []interface{}, re *engin
 	return result, nil
 }
 
+// applyLogicalXor is true when an odd number of the rules are true.
+func applyLogicalXor(input map[string]interface{}, rules []interface{}, re *engine) (bool, error) {
+	result := false
+	for _, nestedRule := range rules {
+		switch r := nestedRule.(type) {
+		case map[string]interface{}:
+			if _, ok := r["rules"]; ok {
+				ruleSetResult, err := applyMapRuleSet(input, r, re)
+				if err != nil {
+					return false, err
+				}
+				switch r := ruleSetResult.(type) {
+				case bool:
+					result = result != r
+				}
+			} else {
+				ruleResult, err := applyMapRule(input, r, re)
+				if err != nil {
+					return false, err
+				}
+				result = result != ruleResult
+			}
+		case Rule:
+			ruleResult, ruleErr := re.applyRule(input, r)
+			if ruleErr != nil {
+				return false, ruleErr
+			}
+
+			switch r := ruleResult.(type) {
+			case bool:
+				result = result != r
+			}
+		default:
+			return false, errors.New(fmt.Sprintf("invalid nested rule type: %s", reflect.TypeOf(nestedRule)))
+		}
+	}
+
+	return result, nil
+}
+
+func applyLogicalNot(result bool, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	return !result, nil
+}
+
 func applyMapRuleSet(input map[string]interface{}, ruleMap map[string]interface{}, re *engine) (interface{}, error) {
 	var ruleSet RuleSet
 	cfg := &mapstructure.DecoderConfig{
@@ -250,7 +383,7 @@ func applyMapRuleSet(input map[string]interface{}, ruleMap map[string]interface{
 		return false, err
 	}
 
-	return re.applyRuleSet(input, ruleSet)
+	return re.evaluateRuleSet(input, ruleSet)
 }
 
 func applyMapRule(input map[string]interface{}, ruleMap map[string]interface{}, re *engine) (bool, error) {
@@ -280,195 +413,212 @@ func applyMapRule(input map[string]interface{}, ruleMap map[string]interface{},
 	}
 }
 
-func applyAction(input map[string]interface{}, action Action) (result interface{}) {
-	switch action.Type {
-	case actiontypes.ReplaceString:
-		params := action.Params
-		result = regexp.MustCompile(params.Pattern).ReplaceAllString(input[params.Name].(string), params.Replacement)
-	case actiontypes.ReturnValue:
-		params := action.Params
-		if v, ok := input[params.Name]; ok {
-			result = v
-		} else {
-			result = params.Replacement
-		}
-	default:
-		// Ignore unknown action
+func decodeMapRuleSet(ruleMap map[string]interface{}) (ruleSet RuleSet, err error) {
+	cfg := &mapstructure.DecoderConfig{
+		Metadata: nil,
+		Result:   &ruleSet,
+		TagName:  "json",
 	}
-
+	decoder, _ := mapstructure.NewDecoder(cfg)
+	err = decoder.Decode(ruleMap)
 	return
 }
 
-func evaluateConditions(input map[string]interface{}, condition Condition) bool {
-	if condition.LogicalOperator == logicaloperators.And {
-		for _, subCondition := range condition.Conditions {
-			if !evaluateConditions(input, subCondition) {
-				return false
-			}
-		}
-		return true
-	} else if condition.LogicalOperator == logicaloperators.Or {
-		for _, subCondition := range condition.Conditions {
-			if evaluateConditions(input, subCondition) {
-				return true
-			}
-		}
-		return false
-	}
-
-	switch condition.Operator {
-	case operators.Equals:
-		return isEqual(input[condition.Name], condition.Value)
-	case operators.GreaterThan:
-		return isGreaterThan(input[condition.Name], condition.Value)
-	case operators.GreaterThanEquals:
-		return isGreaterThanOrEqual(input[condition.Name], condition.Value)
-	case operators.LessThan:
-		return isLessThan(input[condition.Name], condition.Value)
-	case operators.LessThanEquals:
-		return isLessThanOrEqual(input[condition.Name], condition.Value)
-	case operators.NotEquals:
-		return isNotEqual(input[condition.Name], condition.Value)
-	case operators.Match:
-		match, _ := regexp.MatchString(condition.Value.(string), fmt.Sprintf("%v", input[condition.Name]))
-		return match
-	default:
-		log.Fatal("Invalid condition operator: ", condition.Operator)
+func decodeMapRule(ruleMap map[string]interface{}) (rule Rule, err error) {
+	cfg := &mapstructure.DecoderConfig{
+		Metadata: nil,
+		Result:   &rule,
+		TagName:  "json",
 	}
-
-	return false
+	decoder, _ := mapstructure.NewDecoder(cfg)
+	err = decoder.Decode(ruleMap)
+	return
 }
 
-func isEqual(a, b interface{}) bool {
-	switch a := a.(type) {
-	case int:
-		if val, ok := b.(int); ok {
-			return a == val
-		}
-		if val, ok := b.(float64); ok {
-			return a == int(val)
-		}
-	case float64:
-		if val, ok := b.(float64); ok {
-			return a == val
-		}
-	case string:
-		if val, ok := b.(string); ok {
-			return a == val
-		}
+func decodeMapCondition(conditionMap map[string]interface{}, condition *Condition) error {
+	cfg := &mapstructure.DecoderConfig{
+		Metadata: nil,
+		Result:   condition,
+		TagName:  "json",
 	}
-	return false
+	decoder, _ := mapstructure.NewDecoder(cfg)
+	return decoder.Decode(conditionMap)
 }
 
-func isGreaterThan(a, b interface{}) bool {
-	if val, ok := a.(string); ok {
-		if intVal, err := strconv.ParseInt(val, 10, 64); err == nil {
-			a = int(intVal)
+func (re *engine) applyAction(input map[string]interface{}, action Action) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = nil
+			err = fmt.Errorf("action %s panicked: %v", action.Type, r)
 		}
+	}()
+
+	params := action.Params
+	if params == nil && isKnownActionType(action.Type) {
+		return nil, fmt.Errorf("action %s requires params", action.Type)
 	}
 
-	switch a := a.(type) {
-	case int:
-		if val, ok := b.(int); ok {
-			return a > val
+	switch action.Type {
+	case actiontypes.ReplaceString:
+		value, ok := input[params.Name]
+		if !ok {
+			return nil, fmt.Errorf("action %s: field %s is missing", action.Type, params.Name)
 		}
-		if val, ok := b.(float64); ok {
-			return a > int(val)
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("action %s: field %s is %T, not a string", action.Type, params.Name, value)
 		}
-	case float64:
-		if val, ok := b.(float64); ok {
-			return a > val
+		regex, compileErr := re.config.regexes.get(params.Pattern)
+		if compileErr != nil {
+			return nil, compileErr
 		}
+		result = regex.ReplaceAllString(str, params.Replacement)
+		re.writeFact(input, params.Name, result)
+	case actiontypes.ReturnValue:
+		if v, ok := input[params.Name]; ok {
+			result = v
+		} else {
+			result = params.Replacement
+		}
+	case actiontypes.Template:
+		rendered, renderErr := re.renderTemplate(input, params.Template)
+		if renderErr != nil {
+			return nil, fmt.Errorf("action %s: %w", action.Type, renderErr)
+		}
+		result = rendered
+		if params.Name != "" {
+			re.writeFact(input, params.Name, result)
+		}
+	case actiontypes.Compute:
+		expression, parseErr := re.config.expressions.get(params.Expression)
+		if parseErr != nil {
+			return nil, fmt.Errorf("action %s: %w", action.Type, parseErr)
+		}
+		value, evalErr := expression.evaluate(input)
+		if evalErr != nil {
+			return nil, fmt.Errorf("action %s: %w", action.Type, evalErr)
+		}
+		result = value
+		re.writeFact(input, params.Name, result)
+	case actiontypes.SetValue:
+		result = params.Value
+		re.writeFact(input, params.Name, result)
+	case actiontypes.DeleteField:
+		result = input[params.Name]
+		if re.config.actionChaining {
+			delete(input, params.Name)
+		}
+	case actiontypes.Rename:
+		value, ok := input[params.Name]
+		if !ok {
+			return nil, fmt.Errorf("action %s: field %s is missing", action.Type, params.Name)
+		}
+		if params.Target == "" {
+			return nil, fmt.Errorf("action %s requires a target name", action.Type)
+		}
+		result = value
+		if re.config.actionChaining {
+			delete(input, params.Name)
+			input[params.Target] = value
+		}
+	default:
+		// Ignore unknown action
 	}
-	return false
+
+	return
 }
 
-func isGreaterThanOrEqual(a, b interface{}) bool {
-	if val, ok := a.(string); ok {
-		if intVal, err := strconv.ParseInt(val, 10, 64); err == nil {
-			a = int(intVal)
-		}
+// writeFact only updates the facts when actions are chained, where input is the
+// engine's own copy rather than the caller's map.
+func (re *engine) writeFact(input map[string]interface{}, name string, value interface{}) {
+	if re.config.actionChaining {
+		input[name] = value
 	}
+}
 
-	switch a := a.(type) {
-	case int:
-		if val, ok := b.(int); ok {
-			return a >= val
-		}
-		if val, ok := b.(float64); ok {
-			return a >= int(val)
-		}
-	case float64:
-		if val, ok := b.(float64); ok {
-			return a >= val
-		}
+func isKnownActionType(actionType string) bool {
+	switch actionType {
+	case actiontypes.ReplaceString, actiontypes.ReturnValue, actiontypes.Template, actiontypes.Compute, actiontypes.SetValue, actiontypes.DeleteField, actiontypes.Rename:
+		return true
 	}
 	return false
 }
 
-func isLessThan(a, b interface{}) bool {
-	if val, ok := a.(string); ok {
-		if intVal, err := strconv.ParseInt(val, 10, 64); err == nil {
-			a = int(intVal)
-		}
+func cloneInput(input map[string]interface{}) map[string]interface{} {
+	clone := make(map[string]interface{}, len(input))
+	for key, value := range input {
+		clone[key] = value
 	}
+	return clone
+}
 
-	switch a := a.(type) {
-	case int:
-		if val, ok := b.(int); ok {
-			return a < val
+func (re *engine) evaluateConditions(input map[string]interface{}, condition Condition) bool {
+	switch condition.LogicalOperator {
+	case logicaloperators.And:
+		for _, subCondition := range condition.Conditions {
+			if !re.evaluateConditions(input, subCondition) {
+				return false
+			}
 		}
-		if val, ok := b.(float64); ok {
-			return a < int(val)
+		return true
+	case logicaloperators.Or:
+		for _, subCondition := range condition.Conditions {
+			if re.evaluateConditions(input, subCondition) {
+				return true
+			}
 		}
-	case float64:
-		if val, ok := b.(float64); ok {
-			return a < val
+		return false
+	case logicaloperators.Not, logicaloperators.Nand:
+		return !re.evaluateConditions(input, Condition{LogicalOperator: logicaloperators.And, Conditions: condition.Conditions})
+	case logicaloperators.Nor:
+		return !re.evaluateConditions(input, Condition{LogicalOperator: logicaloperators.Or, Conditions: condition.Conditions})
+	case logicaloperators.Xor:
+		result := false
+		for _, subCondition := range condition.Conditions {
+			result = result != re.evaluateConditions(input, subCondition)
 		}
+		return result
 	}
-	return false
-}
 
-func isLessThanOrEqual(a, b interface{}) bool {
-	if val, ok := a.(string); ok {
-		if intVal, err := strconv.ParseInt(val, 10, 64); err == nil {
-			a = int(intVal)
-		}
+	if condition.Quantifier != "" {
+		return re.evaluateQuantifier(input, condition)
 	}
 
-	switch a := a.(type) {
-	case int:
-		if val, ok := b.(int); ok {
-			return a <= val
-		}
-		if val, ok := b.(float64); ok {
-			return a <= int(val)
-		}
-	case float64:
-		if val, ok := b.(float64); ok {
-			return a <= val
-		}
+	if condition.Window != nil {
+		return re.evaluateComparison(re.windowValues[condition.Window.id()], condition.Operator, condition.Value)
 	}
-	return false
+	return re.evaluateComparison(re.fieldValue(input, condition.Name), condition.Operator, condition.Value)
 }
 
-func isNotEqual(a, b interface{}) bool {
-	switch a := a.(type) {
-	case int:
-		if val, ok := b.(int); ok {
-			return a != val
-		}
-		if val, ok := b.(float64); ok {
-			return a != int(val)
-		}
-	case float64:
-		if val, ok := b.(float64); ok {
-			return a != val
+func (re *engine) evaluateComparison(value interface{}, operator string, expected interface{}) bool {
+	switch operator {
+	case operators.Equals:
+		return re.isEqual(value, expected)
+	case operators.GreaterThan:
+		return re.isGreaterThan(value, expected)
+	case operators.GreaterThanEquals:
+		return re.isGreaterThanOrEqual(value, expected)
+	case operators.LessThan:
+		return re.isLessThan(value, expected)
+	case operators.LessThanEquals:
+		return re.isLessThanOrEqual(value, expected)
+	case operators.NotEquals:
+		return re.isNotEqual(value, expected)
+	case operators.Match:
+		pattern, ok := expected.(string)
+		if !ok {
+			return false
 		}
-	case string:
-		if val, ok := b.(string); ok {
-			return a != val
+		regex, err := re.config.regexes.get(pattern)
+		if err != nil {
+			return false
 		}
+		return regex.MatchString(fmt.Sprintf("%v", value))
+	case operators.In:
+		return re.isIn(value, expected)
+	default:
+		log.Fatal("Invalid condition operator: ", operator)
 	}
+
 	return false
 }
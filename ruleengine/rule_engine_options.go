@@ -0,0 +1,120 @@
+package ruleengine
+
+import (
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/action-error-policy"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/decimal"
+	"time"
+)
+
+type Option func(config *engineConfig)
+
+type engineConfig struct {
+	regexCacheSize    int
+	regexLimits       RegexLimits
+	regexes           *regexCache
+	templates         *templateCache
+	expressions       *expressionCache
+	aggregates        *aggregateCache
+	actionErrorPolicy string
+	actionChaining    bool
+	numericPrecision  *NumericPrecision
+	schema            *Schema
+	stateStore        StateStore
+	clock             func() time.Time
+	fragments         *FragmentRegistry
+}
+
+func newEngineConfig(opts ...Option) *engineConfig {
+	config := &engineConfig{
+		actionErrorPolicy: actionerrorpolicies.Continue,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	config.regexes = newRegexCache(config.regexCacheSize, config.regexLimits)
+	config.templates = newTemplateCache()
+	config.expressions = newExpressionCache()
+	config.aggregates = newAggregateCache()
+	if config.stateStore == nil {
+		config.stateStore = NewMemoryStateStore()
+	}
+	if config.clock == nil {
+		config.clock = time.Now
+	}
+	return config
+}
+
+func WithRegexCacheSize(size int) Option {
+	return func(config *engineConfig) {
+		config.regexCacheSize = size
+	}
+}
+
+func WithRegexLimits(limits RegexLimits) Option {
+	return func(config *engineConfig) {
+		config.regexLimits = limits
+	}
+}
+
+func WithActionErrorPolicy(policy string) Option {
+	return func(config *engineConfig) {
+		config.actionErrorPolicy = policy
+	}
+}
+
+// WithActionChaining runs the actions of a matched rule set in order against a copy
+// of the input, so each action sees the changes of the previous ones, and returns
+// the transformed copy in EngineResult.Output.
+func WithActionChaining() Option {
+	return func(config *engineConfig) {
+		config.actionChaining = true
+	}
+}
+
+// WithNumericPrecision rounds both sides of numeric comparisons to scale digits
+// after the point before comparing them. Without it comparisons are exact.
+func WithNumericPrecision(scale int32, rounding decimal.RoundingMode) Option {
+	return func(config *engineConfig) {
+		config.numericPrecision = &NumericPrecision{Scale: scale, Rounding: rounding}
+	}
+}
+
+// WithSchema type-checks every condition against the schema when a rule set is
+// registered and validates every input against it before evaluation.
+func WithSchema(schema Schema) Option {
+	return func(config *engineConfig) {
+		config.schema = &schema
+	}
+}
+
+// WithStateStore keeps the events of window conditions in store instead of the
+// default in-memory store, e.g. to share them between engines or keep them on disk.
+func WithStateStore(store StateStore) Option {
+	return func(config *engineConfig) {
+		config.stateStore = store
+	}
+}
+
+// WithClock replaces time.Now as the time of events recorded for window conditions.
+func WithClock(clock func() time.Time) Option {
+	return func(config *engineConfig) {
+		config.clock = clock
+	}
+}
+
+// WithFragments resolves the $ref references of rule sets against registry when
+// they are registered.
+func WithFragments(registry *FragmentRegistry) Option {
+	return func(config *engineConfig) {
+		config.fragments = registry
+	}
+}
+
+func (re *engine) fork() *engine {
+	return &engine{
+		ruleSet:     re.ruleSet,
+		windows:     re.windows,
+		ruleResults: make(map[string]interface{}),
+		config:      re.config,
+	}
+}
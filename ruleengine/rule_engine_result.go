@@ -4,6 +4,8 @@ type EngineResult struct {
 	Valid    bool                   `json:"valid"`
 	Actions  []ActionResult         `json:"actions,omitempty"`
 	Metadata map[string]interface{} `json:"metadata"`
+	Output   map[string]interface{} `json:"output,omitempty"`
+	Computed map[string]interface{} `json:"computed,omitempty"`
 	Error    string                 `json:"error,omitempty"`
 }
 
@@ -11,4 +13,5 @@ type ActionResult struct {
 	Type   string        `json:"type"`
 	Params *ActionParams `json:"params"`
 	Result interface{}   `json:"result"`
+	Error  string        `json:"error,omitempty"`
 }
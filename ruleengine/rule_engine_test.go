@@ -2,6 +2,8 @@ package ruleengine
 
 import (
 	"encoding/json"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/action-error-policy"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/action-type"
 	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
 	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
 	"reflect"
@@ -445,3 +447,243 @@ func Test_ruleEngine_ApplyRuleSet(t *testing.T) {
 		})
 	}
 }
+
+func Test_ruleEngine_ApplyActionErrors(t *testing.T) {
+	ruleSet := RuleSet{
+		Rules: []interface{}{
+			Rule{ID: 1, Condition: NewGroupCondition(logicaloperators.And, NewCondition("amount", operators.GreaterThan, 1))},
+		},
+		Actions: []Action{
+			{Type: actiontypes.ReplaceString, Params: &ActionParams{Name: "amount", Pattern: "[0-9]", Replacement: "x"}},
+			{Type: actiontypes.ReturnValue},
+			{Type: actiontypes.ReturnValue, Params: &ActionParams{Name: "amount"}},
+		},
+	}
+	tests := []struct {
+		name          string
+		policy        string
+		actionErrors  []bool
+		expectedError bool
+	}{
+		{
+			name:         "Continue",
+			policy:       actionerrorpolicies.Continue,
+			actionErrors: []bool{true, true, false},
+		},
+		{
+			name:         "Stop remaining actions",
+			policy:       actionerrorpolicies.StopActions,
+			actionErrors: []bool{true},
+		},
+		{
+			name:          "Fail result",
+			policy:        actionerrorpolicies.FailResult,
+			actionErrors:  []bool{true},
+			expectedError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewRuleEngine(WithActionErrorPolicy(tt.policy)).
+				RegisterRuleSet(ruleSet).
+				Apply(map[string]interface{}{"amount": 5000}).
+				GetResult()
+			if !result.Valid {
+				t.Fatalf("Expected valid result")
+			}
+			if (result.Error != "") != tt.expectedError {
+				t.Errorf("Unexpected result error: %q", result.Error)
+			}
+			if len(result.Actions) != len(tt.actionErrors) {
+				t.Fatalf("Unexpected action count. Expected: %d, Got: %d", len(tt.actionErrors), len(result.Actions))
+			}
+			for i, expected := range tt.actionErrors {
+				if (result.Actions[i].Error != "") != expected {
+					t.Errorf("Unexpected error for action %d: %q", i, result.Actions[i].Error)
+				}
+			}
+		})
+	}
+}
+
+func Test_ruleEngine_ApplyActionChaining(t *testing.T) {
+	ruleSet := RuleSet{
+		Rules: []interface{}{
+			Rule{ID: 1, Condition: NewGroupCondition(logicaloperators.And, NewCondition("amount", operators.GreaterThan, 1))},
+		},
+		Actions: []Action{
+			{Type: actiontypes.ReplaceString, Params: &ActionParams{Name: "remark", Pattern: "BFST([0-9]+).*", Replacement: "$1"}},
+			{Type: actiontypes.Rename, Params: &ActionParams{Name: "remark", Target: "reference"}},
+			{Type: actiontypes.SetValue, Params: &ActionParams{Name: "flagged", Value: true}},
+			{Type: actiontypes.DeleteField, Params: &ActionParams{Name: "account_number"}},
+			{Type: actiontypes.ReturnValue, Params: &ActionParams{Name: "reference"}},
+		},
+	}
+	input := map[string]interface{}{
+		"amount":         5000,
+		"account_number": "123343242334",
+		"remark":         "BFST123456",
+	}
+
+	result := NewRuleEngine(WithActionChaining()).RegisterRuleSet(ruleSet).Apply(input).GetResult()
+	expected := map[string]interface{}{
+		"amount":    5000,
+		"reference": "123456",
+		"flagged":   true,
+	}
+	if !reflect.DeepEqual(result.Output, expected) {
+		t.Errorf("Unexpected output. Expected: %v, Got: %v", expected, result.Output)
+	}
+	if result.Actions[4].Result != "123456" {
+		t.Errorf("Expected chained action to see transformed input, Got: %v", result.Actions[4].Result)
+	}
+	if input["remark"] != "BFST123456" || input["account_number"] != "123343242334" {
+		t.Errorf("Input must not be modified, Got: %v", input)
+	}
+
+	result = NewRuleEngine().RegisterRuleSet(ruleSet).Apply(input).GetResult()
+	if result.Output != nil {
+		t.Errorf("Unexpected output without chaining: %v", result.Output)
+	}
+	if result.Actions[4].Result != "" {
+		t.Errorf("Expected unchained action to see original input, Got: %v", result.Actions[4].Result)
+	}
+}
+
+func Test_ruleEngine_ApplyNegatedOperators(t *testing.T) {
+	large := NewCondition("amount", operators.GreaterThan, 2000)
+	manual := NewCondition("remark", operators.Match, "^MANUAL")
+	tests := []struct {
+		name      string
+		input     map[string]interface{}
+		condition Condition
+		expected  bool
+	}{
+		{name: "NOT", input: map[string]interface{}{"amount": 1000}, condition: NewNotCondition(large), expected: true},
+		{name: "NOT match", input: map[string]interface{}{"remark": "MANUAL-1"}, condition: NewNotCondition(manual), expected: false},
+		{name: "NAND", input: map[string]interface{}{"amount": 5000, "remark": "AUTO"}, condition: NewGroupCondition(logicaloperators.Nand, large, manual), expected: true},
+		{name: "NOR", input: map[string]interface{}{"amount": 5000, "remark": "AUTO"}, condition: NewGroupCondition(logicaloperators.Nor, large, manual), expected: false},
+		{name: "XOR one true", input: map[string]interface{}{"amount": 5000, "remark": "AUTO"}, condition: NewGroupCondition(logicaloperators.Xor, large, manual), expected: true},
+		{name: "XOR both true", input: map[string]interface{}{"amount": 5000, "remark": "MANUAL-1"}, condition: NewGroupCondition(logicaloperators.Xor, large, manual), expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ruleSet := RuleSet{Rules: []interface{}{Rule{ID: 1, Condition: NewGroupCondition(logicaloperators.And, tt.condition)}}}
+			output := NewRuleEngine().RegisterRuleSet(ruleSet).Apply(tt.input).GetResult()
+			if output.Error != "" {
+				t.Fatalf("Error applying rule set: %v", output.Error)
+			}
+			if output.Valid != tt.expected {
+				t.Errorf("Unexpected output. Expected: %v, Got: %v", tt.expected, output.Valid)
+			}
+		})
+	}
+
+	ruleSet := `{"logical_operator":"NOR","rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":2000}]}},{"id":2,"condition":{"conditions":[{"name":"remark","operator":"match","value":"^MANUAL"}]}}]}`
+	output := NewRuleEngine().RegisterJsonRuleSet(ruleSet).Apply(map[string]interface{}{"amount": 1000, "remark": "AUTO"}).GetResult()
+	if !output.Valid {
+		t.Errorf("Expected NOR rule set to be valid")
+	}
+	expectedDescription := "Rule id #1 result is false. Rule id #2 result is false. Rule set NOR result is true."
+	if output.Metadata["description"] != expectedDescription {
+		t.Errorf("Unexpected description. Expected: %q, Got: %q", expectedDescription, output.Metadata["description"])
+	}
+
+	invalid := `{"rules":[{"id":1,"condition":{"logical_operator":"IMPLIES","conditions":[]}}]}`
+	if err := NewRuleEngine().RegisterJsonRuleSet(invalid).Err(); err == nil {
+		t.Errorf("Expected unknown logical operator to be rejected")
+	}
+}
+
+func Test_ruleEngine_RegisterUnknownOperator(t *testing.T) {
+	tests := []struct {
+		name    string
+		ruleSet string
+		wantErr string
+	}{
+		{
+			name:    "Unknown operator",
+			ruleSet: `{"rules":[{"id":3,"condition":{"conditions":[{"name":"age","operator":"gte","value":30}]}}]}`,
+			wantErr: `rule id #3: unknown operator "gte" on age`,
+		},
+		{
+			name:    "Missing operator",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"age","value":30}]}}]}`,
+			wantErr: `rule id #1: unknown operator "" on age`,
+		},
+		{
+			name:    "Unknown operator in rule condition",
+			ruleSet: `{"rules":[{"id":1,"condition":{"name":"age","operator":"gte","value":30}}]}`,
+			wantErr: `rule id #1: unknown operator "gte" on age`,
+		},
+		{
+			name:    "Nested group without logical operator",
+			ruleSet: `{"rules":[{"id":2,"condition":{"conditions":[{"conditions":[{"name":"age","operator":"greater_than","value":30}]}]}}]}`,
+			wantErr: "rule id #2: condition group requires a logical operator",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewRuleEngine().RegisterJsonRuleSet(tt.ruleSet).Err()
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_ruleEngine_NestedRuleSets(t *testing.T) {
+	ruleSet := `{"logical_operator":"AND","rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":100}]}},{"logical_operator":"OR","rules":[{"id":2,"condition":{"conditions":[{"name":"country","operator":"equals","value":"ID"}]}},{"id":3,"condition":{"conditions":[{"name":"verified","operator":"equals","value":true}]}}]}]}`
+	negated := `{"logical_operator":"AND","rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":100}]}},{"logical_operator":"NOR","rules":[{"id":2,"condition":{"conditions":[{"name":"country","operator":"equals","value":"ID"}]}},{"id":3,"condition":{"conditions":[{"name":"verified","operator":"equals","value":true}]}}],"actions":[{"type":"Compute","params":{"name":"fee","expression":"amount * 2"}}]}]}`
+	tests := []struct {
+		name        string
+		ruleSet     string
+		input       map[string]interface{}
+		expected    bool
+		description string
+	}{
+		{
+			name:        "Nested rule set matches",
+			ruleSet:     ruleSet,
+			input:       map[string]interface{}{"amount": 500, "country": "SG", "verified": true},
+			expected:    true,
+			description: "Rule id #1 result is true. Rule id #2 result is false. Rule id #3 result is true.",
+		},
+		{
+			name:        "Nested rule set does not match",
+			ruleSet:     ruleSet,
+			input:       map[string]interface{}{"amount": 500, "country": "SG", "verified": false},
+			expected:    false,
+			description: "Rule id #1 result is true. Rule id #2 result is false. Rule id #3 result is false.",
+		},
+		{
+			name:        "Negated nested rule set matches",
+			ruleSet:     negated,
+			input:       map[string]interface{}{"amount": 500, "country": "SG", "verified": false},
+			expected:    true,
+			description: "Rule id #1 result is true. Rule id #2 result is false. Rule id #3 result is false. Rule set NOR result is true.",
+		},
+		{
+			name:        "Negated nested rule set does not match",
+			ruleSet:     negated,
+			input:       map[string]interface{}{"amount": 500, "country": "ID", "verified": false},
+			expected:    false,
+			description: "Rule id #1 result is true. Rule id #2 result is true. Rule id #3 result is false. Rule set NOR result is false.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := NewRuleEngine().RegisterJsonRuleSet(tt.ruleSet).Apply(tt.input).GetResult()
+			if output.Valid != tt.expected {
+				t.Errorf("Unexpected output. Expected: %v, Got: %v", tt.expected, output.Valid)
+			}
+			if output.Metadata["description"] != tt.description {
+				t.Errorf("Unexpected description. Expected: %q, Got: %q", tt.description, output.Metadata["description"])
+			}
+			// Only the actions of the top-level rule set run.
+			if len(output.Actions) != 0 || len(output.Computed) != 0 {
+				t.Errorf("Unexpected actions of a nested rule set: %+v", output.Actions)
+			}
+		})
+	}
+}
@@ -1,7 +1,8 @@
 package ruleengine
 
 type RuleSet struct {
-	LogicalOperator string        `json:"logical_operator,omitempty"`
-	Rules           []interface{} `json:"rules,omitempty"`
-	Actions         []Action      `json:"actions,omitempty"`
+	LogicalOperator string          `json:"logical_operator,omitempty"`
+	Rules           []interface{}   `json:"rules,omitempty"`
+	Actions         []Action        `json:"actions,omitempty"`
+	Template        *TemplateOrigin `json:"template,omitempty"`
 }
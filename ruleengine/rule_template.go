@@ -0,0 +1,331 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/field-type"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// templateParamKey marks a placeholder in the rule set of a template, e.g.
+// {"name": "amount", "operator": "less_than_equals", "value": {"$param": "max_amount"}}.
+const templateParamKey = "$param"
+
+var (
+	templateNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+	templateCallPattern = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_.]*)(?:@([^\s(]+))?\s*\((.*)\)\s*$`)
+)
+
+// RuleTemplate is a rule set whose placeholders are replaced by the values of
+// its parameters when it is instantiated. The rule set is kept as JSON and only
+// decoded after the substitution, so placeholders may stand for any value,
+// including names, operators and weights.
+type RuleTemplate struct {
+	Name    string          `json:"name"`
+	Version string          `json:"version"`
+	Params  []TemplateParam `json:"params,omitempty"`
+	RuleSet json.RawMessage `json:"rule_set"`
+}
+
+// TemplateParam declares a parameter of one of the field types. A parameter
+// without a default must be given a value.
+type TemplateParam struct {
+	Name    string      `json:"name"`
+	Type    string      `json:"type"`
+	Default interface{} `json:"default,omitempty"`
+}
+
+// TemplateOrigin records the template and parameter values a rule set was
+// instantiated from.
+type TemplateOrigin struct {
+	Name    string                 `json:"name"`
+	Version string                 `json:"version"`
+	Params  map[string]interface{} `json:"params"`
+}
+
+func ParseJsonRuleTemplate(templateStr string) (RuleTemplate, error) {
+	var template RuleTemplate
+	err := json.Unmarshal([]byte(templateStr), &template)
+	return template, err
+}
+
+// Validate checks the declared parameters, their defaults and that every
+// placeholder names a declared parameter.
+func (t RuleTemplate) Validate() error {
+	if !templateNamePattern.MatchString(t.Name) {
+		return fmt.Errorf("invalid template name %q", t.Name)
+	}
+	if t.Version == "" {
+		return fmt.Errorf("template %s requires a version", t.Name)
+	}
+	params := make(map[string]bool, len(t.Params))
+	for _, param := range t.Params {
+		if !templateNamePattern.MatchString(param.Name) {
+			return fmt.Errorf("template %s: invalid parameter name %q", t.Name, param.Name)
+		}
+		if params[param.Name] {
+			return fmt.Errorf("template %s: duplicate parameter %s", t.Name, param.Name)
+		}
+		params[param.Name] = true
+		if !isTemplateParamType(param.Type) {
+			return fmt.Errorf("template %s: parameter %s has unknown type %q", t.Name, param.Name, param.Type)
+		}
+		if param.Default != nil && !matchesFieldType(param.Type, param.Default) {
+			return fmt.Errorf("template %s: default of parameter %s must be %s, got %v", t.Name, param.Name, param.Type, param.Default)
+		}
+	}
+	body, err := t.body()
+	if err != nil {
+		return fmt.Errorf("template %s: %w", t.Name, err)
+	}
+	_, err = substituteParams(body, func(name string) (interface{}, error) {
+		if !params[name] {
+			return nil, fmt.Errorf("undeclared parameter %s", name)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("template %s: %w", t.Name, err)
+	}
+	return nil
+}
+
+// Instantiate replaces the placeholders with the given values, or the defaults
+// of the parameters left out, and records the origin in RuleSet.Template. The
+// instance is registered with an engine configured by opts, so an invalid
+// instance fails here rather than when it is registered.
+func (t RuleTemplate) Instantiate(values map[string]interface{}, opts ...Option) (RuleSet, error) {
+	if err := t.Validate(); err != nil {
+		return RuleSet{}, err
+	}
+	params := make(map[string]interface{}, len(t.Params))
+	for _, param := range t.Params {
+		value, ok := values[param.Name]
+		if !ok || value == nil {
+			if param.Default == nil {
+				return RuleSet{}, fmt.Errorf("template %s: parameter %s is required", t.Name, param.Name)
+			}
+			value = param.Default
+		}
+		if !matchesFieldType(param.Type, value) {
+			return RuleSet{}, fmt.Errorf("template %s: parameter %s must be %s, got %v", t.Name, param.Name, param.Type, value)
+		}
+		params[param.Name] = value
+	}
+	var unknown []string
+	for name := range values {
+		if _, ok := params[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return RuleSet{}, fmt.Errorf("template %s: unknown parameters %s", t.Name, strings.Join(unknown, ", "))
+	}
+
+	body, err := t.body()
+	if err != nil {
+		return RuleSet{}, fmt.Errorf("template %s: %w", t.Name, err)
+	}
+	substituted, err := substituteParams(body, func(name string) (interface{}, error) {
+		return params[name], nil
+	})
+	if err != nil {
+		return RuleSet{}, fmt.Errorf("template %s: %w", t.Name, err)
+	}
+	data, err := json.Marshal(substituted)
+	if err != nil {
+		return RuleSet{}, fmt.Errorf("template %s: %w", t.Name, err)
+	}
+	var ruleSet RuleSet
+	if err = json.Unmarshal(data, &ruleSet); err != nil {
+		return RuleSet{}, fmt.Errorf("template %s: %w", t.Name, err)
+	}
+	if err = NewRuleEngine(opts...).RegisterRuleSet(ruleSet).Err(); err != nil {
+		return RuleSet{}, fmt.Errorf("template %s: %w", t.Name, err)
+	}
+	ruleSet.Template = &TemplateOrigin{Name: t.Name, Version: t.Version, Params: params}
+	return ruleSet, nil
+}
+
+// body returns the rule set in its generic JSON form.
+func (t RuleTemplate) body() (interface{}, error) {
+	if len(t.RuleSet) == 0 {
+		return nil, errors.New("rule_set is required")
+	}
+	var body interface{}
+	if err := json.Unmarshal(t.RuleSet, &body); err != nil {
+		return nil, fmt.Errorf("rule_set: %w", err)
+	}
+	if _, ok := body.(map[string]interface{}); !ok {
+		return nil, errors.New("rule_set must be an object")
+	}
+	return body, nil
+}
+
+// substituteParams replaces every {"$param": name} object of a generic JSON value.
+func substituteParams(value interface{}, param func(name string) (interface{}, error)) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v[templateParamKey]; ok {
+			name, isName := ref.(string)
+			if !isName || len(v) != 1 {
+				return nil, fmt.Errorf("invalid placeholder %v: %s must be the only key of its object", v, templateParamKey)
+			}
+			return param(name)
+		}
+		substituted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			item, err := substituteParams(item, param)
+			if err != nil {
+				return nil, err
+			}
+			substituted[key] = item
+		}
+		return substituted, nil
+	case []interface{}:
+		substituted := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			item, err := substituteParams(item, param)
+			if err != nil {
+				return nil, err
+			}
+			substituted = append(substituted, item)
+		}
+		return substituted, nil
+	}
+	return value, nil
+}
+
+func isTemplateParamType(paramType string) bool {
+	switch paramType {
+	case fieldtypes.String, fieldtypes.Number, fieldtypes.Integer, fieldtypes.Boolean, fieldtypes.Array, fieldtypes.Object, fieldtypes.Any:
+		return true
+	}
+	return false
+}
+
+// TemplateRegistry holds every version of the registered templates and
+// instantiates them with the engine options it was created with.
+type TemplateRegistry struct {
+	mu        sync.RWMutex
+	templates map[string][]RuleTemplate
+	opts      []Option
+}
+
+func NewTemplateRegistry(opts ...Option) *TemplateRegistry {
+	return &TemplateRegistry{
+		templates: make(map[string][]RuleTemplate),
+		opts:      opts,
+	}
+}
+
+// Register adds a version of a template. Versions cannot be replaced.
+func (r *TemplateRegistry) Register(template RuleTemplate) error {
+	if err := template.Validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, registered := range r.templates[template.Name] {
+		if registered.Version == template.Version {
+			return fmt.Errorf("template %s version %s is already registered", template.Name, template.Version)
+		}
+	}
+	r.templates[template.Name] = append(r.templates[template.Name], template)
+	return nil
+}
+
+func (r *TemplateRegistry) RegisterJson(templateStr string) error {
+	template, err := ParseJsonRuleTemplate(templateStr)
+	if err != nil {
+		return err
+	}
+	return r.Register(template)
+}
+
+// Get returns the given version of a template, or the last registered one when
+// version is empty.
+func (r *TemplateRegistry) Get(name string, version string) (RuleTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := r.templates[name]
+	if len(versions) == 0 {
+		return RuleTemplate{}, fmt.Errorf("unknown template %s", name)
+	}
+	if version == "" {
+		return versions[len(versions)-1], nil
+	}
+	for _, template := range versions {
+		if template.Version == version {
+			return template, nil
+		}
+	}
+	return RuleTemplate{}, fmt.Errorf("unknown version %s of template %s", version, name)
+}
+
+func (r *TemplateRegistry) Instantiate(name string, version string, values map[string]interface{}) (RuleSet, error) {
+	template, err := r.Get(name, version)
+	if err != nil {
+		return RuleSet{}, err
+	}
+	return template.Instantiate(values, r.opts...)
+}
+
+// InstantiateCall instantiates a template from a call such as
+// limit_rule(max_amount=5000, currency="IDR") or limit_rule@2(max_amount=5000),
+// whose values are JSON literals.
+func (r *TemplateRegistry) InstantiateCall(call string) (RuleSet, error) {
+	name, version, values, err := ParseTemplateCall(call)
+	if err != nil {
+		return RuleSet{}, err
+	}
+	return r.Instantiate(name, version, values)
+}
+
+// ParseTemplateCall splits a template call into the template name, the version
+// given after @, if any, and the parameter values.
+func ParseTemplateCall(call string) (name string, version string, values map[string]interface{}, err error) {
+	match := templateCallPattern.FindStringSubmatch(call)
+	if match == nil {
+		return "", "", nil, fmt.Errorf("invalid template call %q", call)
+	}
+	name, version = match[1], match[2]
+	values = make(map[string]interface{})
+	rest := strings.TrimSpace(match[3])
+	for rest != "" {
+		separator := strings.Index(rest, "=")
+		if separator < 0 {
+			return "", "", nil, fmt.Errorf("invalid template call %q: expected name=value", call)
+		}
+		param := strings.TrimSpace(rest[:separator])
+		if !templateNamePattern.MatchString(param) {
+			return "", "", nil, fmt.Errorf("invalid template call %q: invalid parameter name %q", call, param)
+		}
+		if _, ok := values[param]; ok {
+			return "", "", nil, fmt.Errorf("invalid template call %q: parameter %s given twice", call, param)
+		}
+		decoder := json.NewDecoder(strings.NewReader(rest[separator+1:]))
+		var value interface{}
+		if err = decoder.Decode(&value); err != nil {
+			return "", "", nil, fmt.Errorf("invalid template call %q: value of %s: %w", call, param, err)
+		}
+		values[param] = value
+		rest = strings.TrimSpace(rest[separator+1+int(decoder.InputOffset()):])
+		if rest == "" {
+			break
+		}
+		if !strings.HasPrefix(rest, ",") {
+			return "", "", nil, fmt.Errorf("invalid template call %q: expected , after %s", call, param)
+		}
+		rest = strings.TrimSpace(rest[1:])
+		if rest == "" {
+			return "", "", nil, fmt.Errorf("invalid template call %q: expected a parameter after ,", call)
+		}
+	}
+	return name, version, values, nil
+}
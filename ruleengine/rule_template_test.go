@@ -0,0 +1,256 @@
+package ruleengine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const limitRuleTemplate = `{
+  "name": "limit_rule",
+  "version": "1",
+  "params": [
+    {"name": "max_amount", "type": "number"},
+    {"name": "currency", "type": "string", "default": "IDR"},
+    {"name": "channels", "type": "array", "default": ["web", "app"]}
+  ],
+  "rule_set": {
+    "rules": [{"id": 1, "condition": {"conditions": [
+      {"name": "amount", "operator": "less_than_equals", "value": {"$param": "max_amount"}},
+      {"name": "currency", "operator": "equals", "value": {"$param": "currency"}},
+      {"name": "channel", "operator": "in", "value": {"$param": "channels"}}
+    ]}}]
+  }
+}`
+
+func Test_RuleTemplate_Instantiate(t *testing.T) {
+	template, err := ParseJsonRuleTemplate(limitRuleTemplate)
+	if err != nil {
+		t.Fatalf("Error parsing template: %v", err)
+	}
+	ruleSet, err := template.Instantiate(map[string]interface{}{"max_amount": 5000, "currency": "SGD"})
+	if err != nil {
+		t.Fatalf("Error instantiating template: %v", err)
+	}
+	expectedOrigin := &TemplateOrigin{
+		Name:    "limit_rule",
+		Version: "1",
+		Params:  map[string]interface{}{"max_amount": 5000, "currency": "SGD", "channels": []interface{}{"web", "app"}},
+	}
+	if !reflect.DeepEqual(ruleSet.Template, expectedOrigin) {
+		t.Errorf("Unexpected origin.\nExpected: %+v\nGot:      %+v", expectedOrigin, ruleSet.Template)
+	}
+
+	processor := NewRuleEngine().RegisterRuleSet(ruleSet)
+	if err = processor.Err(); err != nil {
+		t.Fatalf("Error registering instance: %v", err)
+	}
+	tests := []struct {
+		input    map[string]interface{}
+		expected bool
+	}{
+		{input: map[string]interface{}{"amount": 5000, "currency": "SGD", "channel": "app"}, expected: true},
+		{input: map[string]interface{}{"amount": 5001, "currency": "SGD", "channel": "app"}, expected: false},
+		{input: map[string]interface{}{"amount": 10, "currency": "IDR", "channel": "app"}, expected: false},
+		{input: map[string]interface{}{"amount": 10, "currency": "SGD", "channel": "branch"}, expected: false},
+	}
+	for _, tt := range tests {
+		if result := processor.Apply(tt.input).GetResult(); result.Valid != tt.expected {
+			t.Errorf("Input %v: expected %v, got %v", tt.input, tt.expected, result.Valid)
+		}
+	}
+}
+
+func Test_RuleTemplate_InstantiateErrors(t *testing.T) {
+	template, err := ParseJsonRuleTemplate(limitRuleTemplate)
+	if err != nil {
+		t.Fatalf("Error parsing template: %v", err)
+	}
+	tests := []struct {
+		name    string
+		values  map[string]interface{}
+		wantErr string
+	}{
+		{name: "Missing required", values: map[string]interface{}{"currency": "IDR"}, wantErr: "parameter max_amount is required"},
+		{name: "Wrong type", values: map[string]interface{}{"max_amount": "5000"}, wantErr: "parameter max_amount must be number"},
+		{name: "Unknown parameter", values: map[string]interface{}{"max_amount": 1, "limit": 2}, wantErr: "unknown parameters limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := template.Instantiate(tt.values)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_RuleTemplate_InstantiateTypedFields(t *testing.T) {
+	template, err := ParseJsonRuleTemplate(`{
+  "name": "threshold",
+  "version": "1",
+  "params": [
+    {"name": "field", "type": "string"},
+    {"name": "operator", "type": "string", "default": "greater_than"},
+    {"name": "threshold", "type": "number"},
+    {"name": "logic", "type": "any", "default": "AND"}
+  ],
+  "rule_set": {"logical_operator": {"$param": "logic"}, "rules": [{"id": 1, "condition": {"conditions": [
+    {"name": {"$param": "field"}, "operator": {"$param": "operator"}, "value": {"$param": "threshold"}}
+  ]}}]}
+}`)
+	if err != nil {
+		t.Fatalf("Error parsing template: %v", err)
+	}
+	ruleSet, err := template.Instantiate(map[string]interface{}{"field": "score", "operator": "less_than", "threshold": 10})
+	if err != nil {
+		t.Fatalf("Error instantiating template: %v", err)
+	}
+	condition := ruleSet.Rules[0].(map[string]interface{})["condition"].(map[string]interface{})["conditions"].([]interface{})[0]
+	expected := map[string]interface{}{"name": "score", "operator": "less_than", "value": float64(10)}
+	if !reflect.DeepEqual(condition, expected) {
+		t.Errorf("Unexpected condition.\nExpected: %v\nGot:      %v", expected, condition)
+	}
+	if ruleSet.LogicalOperator != "AND" {
+		t.Errorf("Expected logical operator AND, got %q", ruleSet.LogicalOperator)
+	}
+	processor := NewRuleEngine().RegisterRuleSet(ruleSet)
+	if !processor.Apply(map[string]interface{}{"score": 5}).GetResult().Valid {
+		t.Errorf("Expected score 5 to be less than 10")
+	}
+
+	tests := []struct {
+		name    string
+		values  map[string]interface{}
+		wantErr string
+	}{
+		{name: "Invalid rule set", values: map[string]interface{}{"field": "score", "operator": "match", "threshold": 10}, wantErr: "template threshold: "},
+		{name: "Value the rule set cannot hold", values: map[string]interface{}{"field": "score", "threshold": 10, "logic": 1}, wantErr: "cannot unmarshal number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := template.Instantiate(tt.values)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_RuleTemplate_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  string
+	}{
+		{
+			name:     "Undeclared parameter",
+			template: `{"name": "t", "version": "1", "rule_set": {"rules": [{"id": 1, "condition": {"conditions": [{"name": "a", "operator": "equals", "value": {"$param": "x"}}]}}]}}`,
+			wantErr:  "undeclared parameter x",
+		},
+		{
+			name:     "Default of the wrong type",
+			template: `{"name": "t", "version": "1", "params": [{"name": "x", "type": "integer", "default": 1.5}]}`,
+			wantErr:  "default of parameter x must be integer",
+		},
+		{
+			name:     "Unknown type",
+			template: `{"name": "t", "version": "1", "params": [{"name": "x", "type": "decimal"}]}`,
+			wantErr:  `parameter x has unknown type "decimal"`,
+		},
+		{
+			name:     "Missing rule set",
+			template: `{"name": "t", "version": "1"}`,
+			wantErr:  "template t: rule_set is required",
+		},
+		{
+			name:     "Missing version",
+			template: `{"name": "t"}`,
+			wantErr:  "template t requires a version",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := ParseJsonRuleTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("Error parsing template: %v", err)
+			}
+			err = template.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_TemplateRegistry_InstantiateCall(t *testing.T) {
+	registry := NewTemplateRegistry()
+	if err := registry.RegisterJson(limitRuleTemplate); err != nil {
+		t.Fatalf("Error registering template: %v", err)
+	}
+	if err := registry.RegisterJson(limitRuleTemplate); err == nil {
+		t.Errorf("Expected registering the same version twice to fail")
+	}
+	second := strings.Replace(limitRuleTemplate, `"version": "1"`, `"version": "2"`, 1)
+	if err := registry.RegisterJson(second); err != nil {
+		t.Fatalf("Error registering template: %v", err)
+	}
+
+	ruleSet, err := registry.InstantiateCall(`limit_rule(max_amount=5000, currency="IDR", channels=["web", "kiosk"])`)
+	if err != nil {
+		t.Fatalf("Error instantiating call: %v", err)
+	}
+	if ruleSet.Template.Version != "2" || ruleSet.Template.Params["max_amount"] != float64(5000) {
+		t.Errorf("Unexpected origin %+v", ruleSet.Template)
+	}
+	ruleSet, err = registry.InstantiateCall(`limit_rule@1(max_amount=10)`)
+	if err != nil {
+		t.Fatalf("Error instantiating call: %v", err)
+	}
+	if ruleSet.Template.Version != "1" || ruleSet.Template.Params["currency"] != "IDR" {
+		t.Errorf("Unexpected origin %+v", ruleSet.Template)
+	}
+	if _, err = registry.InstantiateCall(`limit_rule@3(max_amount=10)`); err == nil {
+		t.Errorf("Expected an error for an unknown version")
+	}
+}
+
+func Test_ParseTemplateCall(t *testing.T) {
+	tests := []struct {
+		call    string
+		name    string
+		version string
+		values  map[string]interface{}
+		wantErr bool
+	}{
+		{call: "limit_rule()", name: "limit_rule", values: map[string]interface{}{}},
+		{
+			call:   ` products.limit ( a = 1 , b = "x, y" , c = [1, 2] ) `,
+			name:   "products.limit",
+			values: map[string]interface{}{"a": float64(1), "b": "x, y", "c": []interface{}{float64(1), float64(2)}},
+		},
+		{call: "limit_rule@v2(on=true)", name: "limit_rule", version: "v2", values: map[string]interface{}{"on": true}},
+		{call: "limit_rule", wantErr: true},
+		{call: "limit_rule(a=1,)", wantErr: true},
+		{call: "limit_rule(a=1, a=2)", wantErr: true},
+		{call: "limit_rule(a=IDR)", wantErr: true},
+		{call: "limit_rule(a=1 b=2)", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.call, func(t *testing.T) {
+			name, version, values, err := ParseTemplateCall(tt.call)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if name != tt.name || version != tt.version || !reflect.DeepEqual(values, tt.values) {
+				t.Errorf("Expected %s@%s%v, got %s@%s%v", tt.name, tt.version, tt.values, name, version, values)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/field-type"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type Schema struct {
+	Fields       map[string]FieldSchema `json:"fields"`
+	AllowUnknown bool                   `json:"allow_unknown,omitempty"`
+}
+
+type FieldSchema struct {
+	Type     string        `json:"type"`
+	Enum     []interface{} `json:"enum,omitempty"`
+	Required bool          `json:"required,omitempty"`
+}
+
+type SchemaError struct {
+	Problems []string
+}
+
+func (e *SchemaError) Error() string {
+	return "schema violation: " + strings.Join(e.Problems, "; ")
+}
+
+func ParseJsonSchema(schemaStr string) (Schema, error) {
+	var schema Schema
+	err := json.Unmarshal([]byte(schemaStr), &schema)
+	return schema, err
+}
+
+func (re *engine) checkRuleSetTypes(ruleSet RuleSet) error {
+	schema := re.config.schema
+	if schema == nil {
+		return nil
+	}
+	var problems []string
+	err := walkRuleSet(ruleSet, func(rule Rule) error {
+		return walkCondition(rule.Condition, func(condition Condition) error {
+			if problem := re.checkConditionType(condition); problem != "" {
+				problems = append(problems, fmt.Sprintf("rule id #%d: %s", rule.ID, problem))
+			}
+			return nil
+		})
+	}, func(Action) error { return nil })
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return &SchemaError{Problems: problems}
+	}
+	return nil
+}
+
+func (re *engine) checkConditionType(condition Condition) string {
+	if condition.LogicalOperator != "" || condition.Name == "" {
+		return ""
+	}
+	schema := re.config.schema
+	name := condition.Name
+	var aggregate *aggregateField
+	if isAggregateField(condition.Name) {
+		parsed, err := re.config.aggregates.get(condition.Name)
+		if err != nil {
+			return err.Error()
+		}
+		aggregate = &parsed
+		name = parsed.path[0].name
+	}
+	field, ok := schema.Fields[name]
+	if !ok {
+		if schema.AllowUnknown {
+			return ""
+		}
+		return fmt.Sprintf("field %s is not declared in the schema", name)
+	}
+	if field.Type == fieldtypes.Any {
+		return ""
+	}
+	if aggregate != nil {
+		if field.Type != fieldtypes.Array && field.Type != fieldtypes.Object {
+			return fmt.Sprintf("%s cannot be applied to %s field %s", aggregate.function, field.Type, name)
+		}
+		field = FieldSchema{Type: aggregate.resultType()}
+	}
+	if condition.Quantifier != "" {
+		if field.Type != fieldtypes.Array {
+			return fmt.Sprintf("%s cannot be applied to %s field %s", condition.Quantifier, field.Type, condition.Name)
+		}
+		return ""
+	}
+
+	switch condition.Operator {
+	case operators.Equals, operators.NotEquals:
+		return re.checkComparable(condition, field, condition.Value)
+	case operators.In:
+		values := reflect.ValueOf(condition.Value)
+		if values.Kind() != reflect.Slice && values.Kind() != reflect.Array {
+			return fmt.Sprintf("%s on %s requires a list of values", condition.Operator, condition.Name)
+		}
+		for i := 0; i < values.Len(); i++ {
+			if problem := re.checkComparable(condition, field, values.Index(i).Interface()); problem != "" {
+				return problem
+			}
+		}
+	case operators.GreaterThan, operators.GreaterThanEquals, operators.LessThan, operators.LessThanEquals:
+		if field.Type != fieldtypes.Number && field.Type != fieldtypes.Integer {
+			return fmt.Sprintf("%s cannot be applied to %s field %s", condition.Operator, field.Type, condition.Name)
+		}
+		if _, ok := toDecimal(condition.Value); !ok {
+			return fmt.Sprintf("%s on %s requires a number, got %v", condition.Operator, condition.Name, condition.Value)
+		}
+	case operators.Match:
+		if field.Type == fieldtypes.Array || field.Type == fieldtypes.Object {
+			return fmt.Sprintf("%s cannot be applied to %s field %s", condition.Operator, field.Type, condition.Name)
+		}
+	default:
+		return fmt.Sprintf("unknown operator %q on %s", condition.Operator, condition.Name)
+	}
+	return ""
+}
+
+// checkComparable reports rule values that can never equal a valid value of the field.
+func (re *engine) checkComparable(condition Condition, field FieldSchema, value interface{}) string {
+	comparable := matchesFieldType(field.Type, value)
+	if field.Type == fieldtypes.Number || field.Type == fieldtypes.Integer {
+		d, ok := toDecimal(value)
+		comparable = ok && (field.Type == fieldtypes.Number || d.IsInteger())
+	}
+	if !comparable {
+		return fmt.Sprintf("%s on %s field %s can never match %v", condition.Operator, field.Type, condition.Name, value)
+	}
+	if len(field.Enum) > 0 && !re.isIn(value, field.Enum) {
+		return fmt.Sprintf("%v is not an allowed value of %s", value, condition.Name)
+	}
+	return ""
+}
+
+func (re *engine) validateInput(input map[string]interface{}) error {
+	schema := re.config.schema
+	if schema == nil {
+		return nil
+	}
+	var problems []string
+	names := make([]string, 0, len(schema.Fields))
+	for name := range schema.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field := schema.Fields[name]
+		value, ok := input[name]
+		if !ok || value == nil {
+			if field.Required {
+				problems = append(problems, fmt.Sprintf("field %s is required", name))
+			}
+			continue
+		}
+		if !matchesFieldType(field.Type, value) {
+			problems = append(problems, fmt.Sprintf("field %s must be %s, got %T", name, field.Type, value))
+			continue
+		}
+		if len(field.Enum) > 0 && !re.isIn(value, field.Enum) {
+			problems = append(problems, fmt.Sprintf("field %s value %v is not one of %v", name, value, field.Enum))
+		}
+	}
+	if !schema.AllowUnknown {
+		var unknown []string
+		for name := range input {
+			if _, ok := schema.Fields[name]; !ok {
+				unknown = append(unknown, name)
+			}
+		}
+		sort.Strings(unknown)
+		for _, name := range unknown {
+			problems = append(problems, fmt.Sprintf("field %s is not declared in the schema", name))
+		}
+	}
+	if len(problems) > 0 {
+		return &SchemaError{Problems: problems}
+	}
+	return nil
+}
+
+func matchesFieldType(fieldType string, value interface{}) bool {
+	switch fieldType {
+	case fieldtypes.Any:
+		return true
+	case fieldtypes.String:
+		_, ok := toText(value)
+		return ok
+	case fieldtypes.Boolean:
+		return reflect.ValueOf(value).Kind() == reflect.Bool
+	case fieldtypes.Number, fieldtypes.Integer:
+		if _, ok := toText(value); ok {
+			return false
+		}
+		d, ok := toDecimal(value)
+		return ok && (fieldType == fieldtypes.Number || d.IsInteger())
+	case fieldtypes.Array:
+		kind := reflect.ValueOf(value).Kind()
+		return kind == reflect.Slice || kind == reflect.Array
+	case fieldtypes.Object:
+		return reflect.ValueOf(value).Kind() == reflect.Map
+	}
+	return false
+}
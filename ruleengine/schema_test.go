@@ -0,0 +1,120 @@
+package ruleengine
+
+import (
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+  "fields": {
+    "amount": {"type": "number", "required": true},
+    "count": {"type": "integer"},
+    "account_number": {"type": "string"},
+    "currency": {"type": "string", "enum": ["IDR", "USD"]},
+    "verified": {"type": "boolean"}
+  }
+}`
+
+func Test_ruleEngine_RegisterWithSchema(t *testing.T) {
+	schema, err := ParseJsonSchema(testSchema)
+	if err != nil {
+		t.Fatalf("Error parsing schema: %v", err)
+	}
+	tests := []struct {
+		name    string
+		ruleSet string
+		problem string
+	}{
+		{
+			name:    "Valid rule set",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":100},{"name":"currency","operator":"in","value":["IDR"]},{"name":"verified","operator":"equals","value":true}]}}]}`,
+		},
+		{
+			name:    "Ordering on string field",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"account_number","operator":"greater_than","value":100}]}}]}`,
+			problem: "greater_than cannot be applied to string field account_number",
+		},
+		{
+			name:    "Number compared with string",
+			ruleSet: `{"rules":[{"id":2,"condition":{"conditions":[{"name":"amount","operator":"equals","value":"abc"}]}}]}`,
+			problem: "rule id #2: equals on number field amount can never match abc",
+		},
+		{
+			name:    "Integer compared with fraction",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"count","operator":"equals","value":1.5}]}}]}`,
+			problem: "can never match 1.5",
+		},
+		{
+			name:    "Value outside enum",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"currency","operator":"equals","value":"EUR"}]}}]}`,
+			problem: "EUR is not an allowed value of currency",
+		},
+		{
+			name:    "Unknown operator",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"gte","value":100}]}}]}`,
+			problem: `rule id #1: unknown operator "gte" on amount`,
+		},
+		{
+			name:    "Unknown field",
+			ruleSet: `{"rules":[{"id":1,"condition":{"conditions":[{"name":"remark","operator":"equals","value":"x"}]}}]}`,
+			problem: "field remark is not declared in the schema",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewRuleEngine(WithSchema(schema)).RegisterJsonRuleSet(tt.ruleSet).Err()
+			if tt.problem == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Errorf("Expected error containing %q, Got: %v", tt.problem, err)
+			}
+		})
+	}
+}
+
+func Test_ruleEngine_checkConditionType_UnknownOperator(t *testing.T) {
+	schema, err := ParseJsonSchema(testSchema)
+	if err != nil {
+		t.Fatalf("Error parsing schema: %v", err)
+	}
+	re := NewRuleEngine(WithSchema(schema)).(*engine)
+	if problem := re.checkConditionType(NewCondition("amount", "gte", 100)); problem != `unknown operator "gte" on amount` {
+		t.Errorf("Expected an unknown operator problem, got %q", problem)
+	}
+}
+
+func Test_ruleEngine_ApplyWithSchema(t *testing.T) {
+	schema, _ := ParseJsonSchema(testSchema)
+	processor := NewRuleEngine(WithSchema(schema)).
+		RegisterJsonRuleSet(`{"rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":100}]}}]}`)
+	if processor.Err() != nil {
+		t.Fatalf("Error registering rule set: %v", processor.Err())
+	}
+	tests := []struct {
+		name    string
+		input   map[string]interface{}
+		valid   bool
+		problem string
+	}{
+		{name: "Valid input", input: map[string]interface{}{"amount": 500, "currency": "IDR"}, valid: true},
+		{name: "Missing required field", input: map[string]interface{}{"currency": "IDR"}, problem: "field amount is required"},
+		{name: "Wrong type", input: map[string]interface{}{"amount": "500"}, problem: "field amount must be number, got string"},
+		{name: "Not in enum", input: map[string]interface{}{"amount": 500, "currency": "EUR"}, problem: "field currency value EUR is not one of [IDR USD]"},
+		{name: "Unknown field", input: map[string]interface{}{"amount": 500, "remark": "x"}, problem: "field remark is not declared in the schema"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := processor.Apply(tt.input).GetResult()
+			if result.Valid != tt.valid {
+				t.Errorf("Unexpected validity. Expected: %v, Got: %v", tt.valid, result.Valid)
+			}
+			if !strings.Contains(result.Error, tt.problem) || (tt.problem == "") != (result.Error == "") {
+				t.Errorf("Expected error containing %q, Got: %q", tt.problem, result.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/decimal"
+	"sort"
+	"sync"
+)
+
+// Scorecard adds the scores of its matched rules to Base and maps the total to
+// the band with the highest Min not above it. Rules may be nested in rule sets,
+// whose logical operators are ignored: every rule is scored on its own.
+type Scorecard struct {
+	Name       string        `json:"name,omitempty"`
+	Base       float64       `json:"base,omitempty"`
+	Rules      []interface{} `json:"rules"`
+	Bands      []ScoreBand   `json:"bands,omitempty"`
+	TopReasons int           `json:"top_reasons,omitempty"`
+}
+
+// ScoreBin scores a rule by the first of its bins whose condition holds, e.g. one
+// bin per age range. ReasonCode defaults to the rule's.
+type ScoreBin struct {
+	Condition  Condition `json:"condition"`
+	Score      float64   `json:"score"`
+	ReasonCode string    `json:"reason_code,omitempty"`
+}
+
+type ScoreBand struct {
+	Name string  `json:"name"`
+	Min  float64 `json:"min"`
+}
+
+// ScoreResult lists the contributions in rule order and the reason codes of the
+// TopReasons highest contributions, all of them when TopReasons is 0.
+type ScoreResult struct {
+	Total         decimal.Decimal     `json:"total"`
+	Band          string              `json:"band,omitempty"`
+	Contributions []ScoreContribution `json:"contributions"`
+	ReasonCodes   []string            `json:"reason_codes"`
+}
+
+// ScoreContribution is the score added by a matched rule and, for binned rules,
+// the position of the bin from 1.
+type ScoreContribution struct {
+	RuleID     int             `json:"rule_id"`
+	Bin        int             `json:"bin,omitempty"`
+	Score      decimal.Decimal `json:"score"`
+	ReasonCode string          `json:"reason_code,omitempty"`
+}
+
+type ScorecardProcessor struct {
+	mu        sync.Mutex
+	scorecard Scorecard
+	rules     []Rule
+	bands     []ScoreBand
+	engine    *engine
+}
+
+func ParseJsonScorecard(scorecardStr string) (Scorecard, error) {
+	var scorecard Scorecard
+	err := json.Unmarshal([]byte(scorecardStr), &scorecard)
+	return scorecard, err
+}
+
+func NewScorecardProcessor(scorecard Scorecard, opts ...Option) (*ScorecardProcessor, error) {
+	if scorecard.TopReasons < 0 {
+		return nil, errors.New("top reasons cannot be negative")
+	}
+	re := NewRuleEngine(opts...).(*engine)
+	resolved, err := re.resolveRuleSet(RuleSet{Rules: scorecard.Rules})
+	if err != nil {
+		return nil, err
+	}
+	var rules []Rule
+	ids := make(map[int]bool)
+	err = walkRuleSet(resolved, func(rule Rule) error {
+		if ids[rule.ID] {
+			return fmt.Errorf("duplicate rule id %d", rule.ID)
+		}
+		ids[rule.ID] = true
+		if rule.Weight != 0 && len(rule.Bins) > 0 {
+			return fmt.Errorf("rule id #%d has both a weight and bins", rule.ID)
+		}
+		rules = append(rules, rule)
+		return nil
+	}, func(Action) error { return nil })
+	if err != nil {
+		return nil, err
+	}
+
+	bands := append([]ScoreBand(nil), scorecard.Bands...)
+	sort.SliceStable(bands, func(i, j int) bool {
+		return bands[i].Min < bands[j].Min
+	})
+	for i, band := range bands {
+		if band.Name == "" {
+			return nil, errors.New("score band requires a name")
+		}
+		if i > 0 && band.Min == bands[i-1].Min {
+			return nil, fmt.Errorf("score bands %s and %s have the same minimum %v", bands[i-1].Name, band.Name, band.Min)
+		}
+	}
+
+	// Bin conditions are prepared as rules with the ID of their rule, so errors
+	// name the rule they belong to.
+	ruleSet := RuleSet{}
+	for _, rule := range rules {
+		ruleSet.Rules = append(ruleSet.Rules, rule)
+		for _, bin := range rule.Bins {
+			ruleSet.Rules = append(ruleSet.Rules, Rule{ID: rule.ID, Condition: implicitAnd(bin.Condition)})
+		}
+	}
+	if err = re.prepareRuleSet(ruleSet); err != nil {
+		return nil, err
+	}
+	return &ScorecardProcessor{scorecard: scorecard, rules: rules, bands: bands, engine: re}, nil
+}
+
+// Evaluate scores the input. Evaluations are serialized since window conditions
+// record every input.
+func (p *ScorecardProcessor) Evaluate(input map[string]interface{}) (ScoreResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.engine.validateInput(input); err != nil {
+		return ScoreResult{}, err
+	}
+	if err := p.engine.recordWindows(input); err != nil {
+		return ScoreResult{}, err
+	}
+	total, _ := toDecimal(p.scorecard.Base)
+	result := ScoreResult{Contributions: []ScoreContribution{}, ReasonCodes: []string{}}
+	for _, rule := range p.rules {
+		contribution, ok := p.score(input, rule)
+		if !ok {
+			continue
+		}
+		total = total.Add(contribution.Score)
+		result.Contributions = append(result.Contributions, contribution)
+	}
+	result.Total = total
+	for _, band := range p.bands {
+		min, _ := toDecimal(band.Min)
+		if total.Cmp(min) >= 0 {
+			result.Band = band.Name
+		}
+	}
+	result.ReasonCodes = p.reasonCodes(result.Contributions)
+	return result, nil
+}
+
+// score returns the contribution of a rule, which is missing when the rule does
+// not match, has no weight or none of its bins match.
+func (p *ScorecardProcessor) score(input map[string]interface{}, rule Rule) (ScoreContribution, bool) {
+	if !p.engine.evaluateConditions(input, implicitAnd(rule.Condition)) {
+		return ScoreContribution{}, false
+	}
+	contribution := ScoreContribution{RuleID: rule.ID, ReasonCode: rule.ReasonCode}
+	if len(rule.Bins) == 0 {
+		if rule.Weight == 0 {
+			return ScoreContribution{}, false
+		}
+		contribution.Score, _ = toDecimal(rule.Weight)
+		return contribution, true
+	}
+	for i, bin := range rule.Bins {
+		if !p.engine.evaluateConditions(input, implicitAnd(bin.Condition)) {
+			continue
+		}
+		contribution.Bin = i + 1
+		contribution.Score, _ = toDecimal(bin.Score)
+		if bin.ReasonCode != "" {
+			contribution.ReasonCode = bin.ReasonCode
+		}
+		return contribution, true
+	}
+	return ScoreContribution{}, false
+}
+
+// reasonCodes returns the distinct reason codes of the highest contributions,
+// keeping rule order between equal scores.
+func (p *ScorecardProcessor) reasonCodes(contributions []ScoreContribution) []string {
+	ranked := append([]ScoreContribution(nil), contributions...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score.Cmp(ranked[j].Score) > 0
+	})
+	codes := []string{}
+	seen := make(map[string]bool)
+	for _, contribution := range ranked {
+		if p.scorecard.TopReasons > 0 && len(codes) == p.scorecard.TopReasons {
+			break
+		}
+		if contribution.ReasonCode == "" || seen[contribution.ReasonCode] {
+			continue
+		}
+		seen[contribution.ReasonCode] = true
+		codes = append(codes, contribution.ReasonCode)
+	}
+	return codes
+}
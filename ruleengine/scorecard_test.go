@@ -0,0 +1,159 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"reflect"
+	"testing"
+)
+
+const riskScorecard = `{
+  "name": "risk",
+  "base": 100,
+  "top_reasons": 2,
+  "bands": [
+    {"name": "high", "min": 160},
+    {"name": "low", "min": 0},
+    {"name": "medium", "min": 130}
+  ],
+  "rules": [
+    {
+      "id": 1,
+      "condition": {},
+      "reason_code": "AGE",
+      "bins": [
+        {"condition": {"name": "age", "operator": "less_than", "value": 25}, "score": 30, "reason_code": "YOUNG"},
+        {"condition": {"name": "age", "operator": "less_than", "value": 60}, "score": 10}
+      ]
+    },
+    {"id": 2, "condition": {"conditions": [{"name": "country", "operator": "in", "value": ["KP", "IR"]}]}, "weight": 45.5, "reason_code": "COUNTRY"},
+    {"id": 3, "condition": {"conditions": [{"name": "new_device", "operator": "equals", "value": true}]}, "weight": 12, "reason_code": "DEVICE"},
+    {"rules": [
+      {"id": 4, "condition": {"conditions": [{"name": "verified", "operator": "equals", "value": true}]}, "weight": -20, "reason_code": "VERIFIED"}
+    ]}
+  ]
+}`
+
+func Test_ScorecardProcessor_Evaluate(t *testing.T) {
+	scorecard, err := ParseJsonScorecard(riskScorecard)
+	if err != nil {
+		t.Fatalf("Error parsing scorecard: %v", err)
+	}
+	processor, err := NewScorecardProcessor(scorecard)
+	if err != nil {
+		t.Fatalf("Error compiling scorecard: %v", err)
+	}
+	tests := []struct {
+		name     string
+		input    map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "Top reasons",
+			input:    map[string]interface{}{"age": 22, "country": "IR", "new_device": true, "verified": false},
+			expected: `{"total":187.5,"band":"high","contributions":[{"rule_id":1,"bin":1,"score":30,"reason_code":"YOUNG"},{"rule_id":2,"score":45.5,"reason_code":"COUNTRY"},{"rule_id":3,"score":12,"reason_code":"DEVICE"}],"reason_codes":["COUNTRY","YOUNG"]}`,
+		},
+		{
+			name:     "Bin falls back to rule reason code",
+			input:    map[string]interface{}{"age": 40, "country": "ID", "new_device": true, "verified": true},
+			expected: `{"total":102,"band":"low","contributions":[{"rule_id":1,"bin":2,"score":10,"reason_code":"AGE"},{"rule_id":3,"score":12,"reason_code":"DEVICE"},{"rule_id":4,"score":-20,"reason_code":"VERIFIED"}],"reason_codes":["DEVICE","AGE"]}`,
+		},
+		{
+			name:     "No bin matches",
+			input:    map[string]interface{}{"age": 70, "country": "KP", "new_device": false, "verified": false},
+			expected: `{"total":145.5,"band":"medium","contributions":[{"rule_id":2,"score":45.5,"reason_code":"COUNTRY"}],"reason_codes":["COUNTRY"]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := processor.Evaluate(tt.input)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			data, _ := json.Marshal(result)
+			if string(data) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, data)
+			}
+		})
+	}
+}
+
+func Test_ScorecardProcessor_BelowEveryBand(t *testing.T) {
+	processor, err := NewScorecardProcessor(Scorecard{
+		Rules: []interface{}{Rule{ID: 1, Condition: NewGroupCondition("", NewCondition("amount", operators.GreaterThan, 100)), Weight: 0.1}},
+		Bands: []ScoreBand{{Name: "flagged", Min: 0.3}},
+	})
+	if err != nil {
+		t.Fatalf("Error compiling scorecard: %v", err)
+	}
+	result, err := processor.Evaluate(map[string]interface{}{"amount": 200})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Total.String() != "0.1" || result.Band != "" || !reflect.DeepEqual(result.ReasonCodes, []string{}) {
+		t.Errorf("Unexpected result %+v", result)
+	}
+}
+
+func Test_ScorecardProcessor_LeafCondition(t *testing.T) {
+	processor, err := NewScorecardProcessor(Scorecard{
+		Rules: []interface{}{Rule{ID: 1, Condition: NewCondition("age", operators.GreaterThan, 30), Weight: 10}},
+	})
+	if err != nil {
+		t.Fatalf("Error compiling scorecard: %v", err)
+	}
+	tests := []struct {
+		age      int
+		expected string
+	}{
+		{age: 10, expected: "0"},
+		{age: 40, expected: "10"},
+	}
+	for _, tt := range tests {
+		result, err := processor.Evaluate(map[string]interface{}{"age": tt.age})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Total.String() != tt.expected {
+			t.Errorf("Age %d: expected total %s, got %s", tt.age, tt.expected, result.Total)
+		}
+	}
+}
+
+func Test_NewScorecardProcessor_Errors(t *testing.T) {
+	condition := NewGroupCondition("", NewCondition("age", operators.LessThan, 25))
+	tests := []struct {
+		name      string
+		scorecard Scorecard
+	}{
+		{
+			name:      "Weight and bins",
+			scorecard: Scorecard{Rules: []interface{}{Rule{ID: 1, Weight: 1, Bins: []ScoreBin{{Condition: condition, Score: 1}}}}},
+		},
+		{
+			name:      "Duplicate rule id",
+			scorecard: Scorecard{Rules: []interface{}{Rule{ID: 1, Weight: 1}, Rule{ID: 1, Weight: 2}}},
+		},
+		{
+			name:      "Duplicate band minimum",
+			scorecard: Scorecard{Bands: []ScoreBand{{Name: "a", Min: 1}, {Name: "b", Min: 1}}},
+		},
+		{
+			name: "Invalid bin condition",
+			scorecard: Scorecard{Rules: []interface{}{Rule{ID: 1, Bins: []ScoreBin{
+				{Condition: NewCondition("name", operators.Match, "("), Score: 1},
+			}}}},
+		},
+		{
+			name:      "Negative top reasons",
+			scorecard: Scorecard{TopReasons: -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewScorecardProcessor(tt.scorecard); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package ruleengine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// StateStore keeps the events of windowed aggregations across evaluations.
+// Implementations must be safe for concurrent use.
+type StateStore interface {
+	// Append records an event under key, drops the events of key that expired at
+	// now and returns the remaining events, including the new one.
+	Append(key string, event WindowEvent, now time.Time) ([]WindowEvent, error)
+	// Expire drops the events of every key that expired at now.
+	Expire(now time.Time) error
+}
+
+type WindowEvent struct {
+	Time      time.Time   `json:"time"`
+	ExpiresAt time.Time   `json:"expires_at"`
+	Value     interface{} `json:"value,omitempty"`
+}
+
+// memoryStateSweepMin is the number of appends a MemoryStateStore takes before
+// it first expires the events of every key.
+const memoryStateSweepMin = 1024
+
+// MemoryStateStore drops the expired events of a key whenever it appends to it.
+// Keys that stop receiving events, such as those of closed accounts, are expired
+// by a sweep of every key once the appends since the last sweep outnumber the
+// events it kept, so each append stays O(1) amortized.
+type MemoryStateStore struct {
+	mu      sync.Mutex
+	events  map[string][]WindowEvent
+	appends int
+	sweep   int
+}
+
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{
+		events: make(map[string][]WindowEvent),
+		sweep:  memoryStateSweepMin,
+	}
+}
+
+func (s *MemoryStateStore) Append(key string, event WindowEvent, now time.Time) ([]WindowEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := append(unexpiredEvents(s.events[key], now), event)
+	s.events[key] = events
+	s.appends++
+	if s.appends >= s.sweep {
+		s.expire(now)
+	}
+	return append([]WindowEvent(nil), events...), nil
+}
+
+func (s *MemoryStateStore) Expire(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(now)
+	return nil
+}
+
+func (s *MemoryStateStore) expire(now time.Time) {
+	kept := 0
+	for key, events := range s.events {
+		if events = unexpiredEvents(events, now); len(events) > 0 {
+			s.events[key] = events
+			kept += len(events)
+		} else {
+			delete(s.events, key)
+		}
+	}
+	s.appends = 0
+	s.sweep = kept
+	if s.sweep < memoryStateSweepMin {
+		s.sweep = memoryStateSweepMin
+	}
+}
+
+func unexpiredEvents(events []WindowEvent, now time.Time) []WindowEvent {
+	var unexpired []WindowEvent
+	for _, event := range events {
+		if event.ExpiresAt.After(now) {
+			unexpired = append(unexpired, event)
+		}
+	}
+	return unexpired
+}
+
+// fileStateCompactionMin is the number of log records a FileStateStore writes
+// before it first considers compacting its log.
+const fileStateCompactionMin = 1024
+
+// FileStateStore is a MemoryStateStore that appends every change to a JSON
+// lines log and replays it when opened, so state survives restarts. Once the log
+// holds twice as many records as its last compaction wrote, it is rewritten with
+// only the events still stored, keeping each change O(1) amortized.
+type FileStateStore struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	records int
+	compact int
+	memory  *MemoryStateStore
+}
+
+// fileStateRecord is a line of the log: an event appended under Key at Now, or,
+// without a key, an expiry of every key at Now.
+type fileStateRecord struct {
+	Key   string       `json:"key,omitempty"`
+	Event *WindowEvent `json:"event,omitempty"`
+	Now   time.Time    `json:"now"`
+}
+
+func NewFileStateStore(path string) (*FileStateStore, error) {
+	store := &FileStateStore{
+		path:    path,
+		compact: fileStateCompactionMin,
+		memory:  NewMemoryStateStore(),
+	}
+	if err := store.replay(); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	store.file = file
+	return store, nil
+}
+
+// replay loads the log. A last line cut short by a crash is dropped.
+func (s *FileStateStore) replay() error {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	lines := bytes.Split(data, []byte("\n"))
+	offset := 0
+	for i, line := range lines {
+		var record fileStateRecord
+		if len(bytes.TrimSpace(line)) > 0 {
+			if err = json.Unmarshal(line, &record); err != nil {
+				if i == len(lines)-1 {
+					// Later records are appended after the last complete line.
+					return os.Truncate(s.path, int64(offset))
+				}
+				return fmt.Errorf("state file %s line %d: %w", s.path, i+1, err)
+			}
+			s.apply(record)
+			s.records++
+		}
+		offset += len(line) + 1
+	}
+	return nil
+}
+
+func (s *FileStateStore) apply(record fileStateRecord) []WindowEvent {
+	if record.Event == nil {
+		_ = s.memory.Expire(record.Now)
+		return nil
+	}
+	events, _ := s.memory.Append(record.Key, *record.Event, record.Now)
+	return events
+}
+
+func (s *FileStateStore) Append(key string, event WindowEvent, now time.Time) ([]WindowEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record := fileStateRecord{Key: key, Event: &event, Now: now}
+	if err := s.write(record); err != nil {
+		return nil, err
+	}
+	return s.apply(record), s.compactIfNeeded(now)
+}
+
+func (s *FileStateStore) Expire(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record := fileStateRecord{Now: now}
+	if err := s.write(record); err != nil {
+		return err
+	}
+	s.apply(record)
+	return s.compactIfNeeded(now)
+}
+
+// Close closes the log. The store cannot be used afterwards.
+func (s *FileStateStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+func (s *FileStateStore) write(record fileStateRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err = s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	s.records++
+	return nil
+}
+
+// compactIfNeeded expires every key before compacting, so the events of keys
+// that stopped receiving events are not written again.
+func (s *FileStateStore) compactIfNeeded(now time.Time) error {
+	if s.records < s.compact {
+		return nil
+	}
+	_ = s.memory.Expire(now)
+	return s.compactLog()
+}
+
+// compactLog writes the stored events to a temporary file first and renames it
+// over the log, so a crash never leaves a partial log.
+func (s *FileStateStore) compactLog() error {
+	var buffer bytes.Buffer
+	records := 0
+	s.memory.mu.Lock()
+	for key, events := range s.memory.events {
+		for i := range events {
+			data, err := json.Marshal(fileStateRecord{Key: key, Event: &events[i]})
+			if err != nil {
+				s.memory.mu.Unlock()
+				return err
+			}
+			buffer.Write(data)
+			buffer.WriteByte('\n')
+			records++
+		}
+	}
+	s.memory.mu.Unlock()
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, buffer.Bytes(), 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	_ = s.file.Close()
+	s.file = file
+	s.records = records
+	s.compact = 2 * records
+	if s.compact < fileStateCompactionMin {
+		s.compact = fileStateCompactionMin
+	}
+	return nil
+}
@@ -0,0 +1,412 @@
+package ruleengine
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/hit-policy"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	tableIDHeader     = "id"
+	tableOutputPrefix = "=>"
+
+	tableIDColumn     = "id"
+	tableInputColumn  = "input"
+	tableOutputColumn = "output"
+)
+
+// tableOperatorSymbols maps the operator written after the field name in a
+// header, e.g. `amount >=`, to the condition operator.
+var tableOperatorSymbols = map[string]string{
+	"=":                         operators.Equals,
+	"==":                        operators.Equals,
+	"!=":                        operators.NotEquals,
+	">":                         operators.GreaterThan,
+	">=":                        operators.GreaterThanEquals,
+	"<":                         operators.LessThan,
+	"<=":                        operators.LessThanEquals,
+	"~":                         operators.Match,
+	operators.Equals:            operators.Equals,
+	operators.NotEquals:         operators.NotEquals,
+	operators.GreaterThan:       operators.GreaterThan,
+	operators.GreaterThanEquals: operators.GreaterThanEquals,
+	operators.LessThan:          operators.LessThan,
+	operators.LessThanEquals:    operators.LessThanEquals,
+	operators.Match:             operators.Match,
+	operators.In:                operators.In,
+}
+
+// tableOperatorLike matches the last word of a header that is meant as an
+// operator but is not one of tableOperatorSymbols, e.g. `>==` or `gte`, so a
+// misspelled operator is not read as part of the field name.
+var tableOperatorLike = regexp.MustCompile(`^([=!<>~]+|(?i:eq|ne|neq|gt|gte|ge|lt|lte|le|nin|like|regex|contains|between|equal|matches|(equal|not|greater|less|match)_\w+))$`)
+
+var tableHeaderSymbols = map[string]string{
+	operators.NotEquals:         "!=",
+	operators.GreaterThan:       ">",
+	operators.GreaterThanEquals: ">=",
+	operators.LessThan:          "<",
+	operators.LessThanEquals:    "<=",
+	operators.Match:             "~",
+	operators.In:                "in",
+}
+
+// TableImportOptions configures ImportCSVDecisionTable and ImportXLSXDecisionTable.
+// HitPolicy defaults to UNIQUE and Comma to ','.
+type TableImportOptions struct {
+	HitPolicy string
+	Comma     rune
+}
+
+// TableImportError points to the cell of the imported file that could not be
+// read, numbering rows and columns from 1 as a spreadsheet does.
+type TableImportError struct {
+	Row    int
+	Column int
+	Err    error
+}
+
+func (e *TableImportError) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+	}
+	return fmt.Sprintf("row %d, column %d: %v", e.Row, e.Column, e.Err)
+}
+
+func (e *TableImportError) Unwrap() error {
+	return e.Err
+}
+
+// tableRecord is a row of an imported file with its row number from 1.
+type tableRecord struct {
+	row   int
+	cells []string
+}
+
+type tableColumn struct {
+	kind  string
+	input TableInput
+	name  string
+}
+
+// ImportCSVDecisionTable reads a decision table whose header declares the input
+// columns as a field name optionally followed by an operator (`amount >=`,
+// `country in`), output columns as `=> name` and an optional `id` column.
+func ImportCSVDecisionTable(r io.Reader, opts TableImportOptions) (DecisionTable, error) {
+	reader := csv.NewReader(r)
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+	reader.FieldsPerRecord = -1
+	var records []tableRecord
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return DecisionTable{}, &TableImportError{Row: parseErr.StartLine, Column: parseErr.Column, Err: parseErr.Err}
+			}
+			return DecisionTable{}, err
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, tableRecord{row: line, cells: record})
+	}
+	return decisionTableFromRecords(records, opts)
+}
+
+func decisionTableFromRecords(records []tableRecord, opts TableImportOptions) (DecisionTable, error) {
+	for len(records) > 0 && isBlankRecord(records[0].cells) {
+		records = records[1:]
+	}
+	if len(records) == 0 {
+		return DecisionTable{}, &TableImportError{Row: 1, Err: errors.New("missing header row")}
+	}
+	table := DecisionTable{HitPolicy: opts.HitPolicy}
+	if table.HitPolicy == "" {
+		table.HitPolicy = hitpolicies.Unique
+	}
+
+	headerRow := records[0].row
+	columns := make([]tableColumn, 0, len(records[0].cells))
+	hasID := false
+	for i, header := range records[0].cells {
+		column, err := parseTableHeader(header)
+		if err != nil {
+			return DecisionTable{}, &TableImportError{Row: headerRow, Column: i + 1, Err: err}
+		}
+		switch column.kind {
+		case tableIDColumn:
+			if hasID {
+				return DecisionTable{}, &TableImportError{Row: headerRow, Column: i + 1, Err: errors.New("duplicate id column")}
+			}
+			hasID = true
+		case tableInputColumn:
+			table.Inputs = append(table.Inputs, column.input)
+		case tableOutputColumn:
+			table.Outputs = append(table.Outputs, TableOutput{Name: column.name})
+		}
+		columns = append(columns, column)
+	}
+	if len(table.Inputs) == 0 {
+		return DecisionTable{}, &TableImportError{Row: headerRow, Err: errors.New("header declares no input columns")}
+	}
+
+	ids := make(map[int]int)
+	for _, tableRecord := range records[1:] {
+		rowNumber, record := tableRecord.row, tableRecord.cells
+		if isBlankRecord(record) {
+			continue
+		}
+		if len(record) > len(columns) {
+			return DecisionTable{}, &TableImportError{Row: rowNumber, Column: len(columns) + 1, Err: errors.New("cell outside the header columns")}
+		}
+		row := TableRow{}
+		for i, column := range columns {
+			text := ""
+			if i < len(record) {
+				text = strings.TrimSpace(record[i])
+			}
+			switch column.kind {
+			case tableIDColumn:
+				id, err := strconv.Atoi(text)
+				if err != nil || id <= 0 {
+					return DecisionTable{}, &TableImportError{Row: rowNumber, Column: i + 1, Err: fmt.Errorf("invalid rule id %q", text)}
+				}
+				if previous, ok := ids[id]; ok {
+					return DecisionTable{}, &TableImportError{Row: rowNumber, Column: i + 1, Err: fmt.Errorf("rule id %d is already used in row %d", id, previous)}
+				}
+				ids[id] = rowNumber
+				row.ID = id
+			case tableInputColumn:
+				cell, err := parseTableCell(column.input.operator(), text)
+				if err != nil {
+					return DecisionTable{}, &TableImportError{Row: rowNumber, Column: i + 1, Err: err}
+				}
+				row.Inputs = append(row.Inputs, cell)
+			case tableOutputColumn:
+				var value interface{}
+				if text != "" {
+					value = parseTableValue(text)
+				}
+				row.Outputs = append(row.Outputs, value)
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func parseTableHeader(header string) (tableColumn, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return tableColumn{}, errors.New("empty header")
+	}
+	if strings.EqualFold(header, tableIDHeader) {
+		return tableColumn{kind: tableIDColumn}, nil
+	}
+	if strings.HasPrefix(header, tableOutputPrefix) {
+		name := strings.TrimSpace(strings.TrimPrefix(header, tableOutputPrefix))
+		if name == "" {
+			return tableColumn{}, errors.New("output column requires a name")
+		}
+		return tableColumn{kind: tableOutputColumn, name: name}, nil
+	}
+	if i := strings.LastIndexByte(header, ' '); i > 0 {
+		if operator, ok := tableOperatorSymbols[strings.ToLower(header[i+1:])]; ok {
+			name := strings.TrimSpace(header[:i])
+			return tableColumn{kind: tableInputColumn, input: TableInput{Name: name, Operator: operator}}, nil
+		}
+		if tableOperatorLike.MatchString(header[i+1:]) {
+			return tableColumn{}, fmt.Errorf("column %q: unknown operator %s", header, header[i+1:])
+		}
+	}
+	return tableColumn{kind: tableInputColumn, input: TableInput{Name: header}}, nil
+}
+
+// parseTableCell reads a cell as JSON when it is valid JSON and as text
+// otherwise. Cells of `in` columns may also list values separated by commas.
+func parseTableCell(operator string, text string) (interface{}, error) {
+	if text == "" || text == tableWildcard {
+		return nil, nil
+	}
+	value := parseTableValue(text)
+	if value == nil {
+		return nil, errors.New("null cells are not supported: leave the cell empty or write - to match any value")
+	}
+	switch operator {
+	case operators.In:
+		if _, ok := value.([]interface{}); ok {
+			return value, nil
+		}
+		var values []interface{}
+		for _, part := range strings.Split(text, ",") {
+			values = append(values, parseTableValue(strings.TrimSpace(part)))
+		}
+		return values, nil
+	case operators.GreaterThan, operators.GreaterThanEquals, operators.LessThan, operators.LessThanEquals:
+		if _, ok := toDecimal(value); !ok {
+			return nil, fmt.Errorf("%s requires a number, got %q", operator, text)
+		}
+	case operators.Match:
+		pattern, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s requires a pattern, got %q", operator, text)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return value, nil
+}
+
+func parseTableValue(text string) interface{} {
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err == nil {
+		return value
+	}
+	return text
+}
+
+// ExportRuleSetCSV writes a rule set whose rules are ANDs of predicates as a CSV
+// table with an id column and one column per field and operator, which
+// ImportCSVDecisionTable reads back. An imported table matches when any row
+// matches, so only OR rule sets, or rule sets of a single rule, without nested
+// rule sets or actions can be exported.
+func ExportRuleSetCSV(w io.Writer, ruleSet RuleSet) error {
+	type columnKey struct {
+		name     string
+		operator string
+	}
+	var (
+		columns []columnKey
+		rows    []map[columnKey]interface{}
+		ids     []int
+	)
+	if ruleSet.LogicalOperator != logicaloperators.Or && len(ruleSet.Rules) > 1 {
+		operator := ruleSet.LogicalOperator
+		if operator == "" {
+			operator = logicaloperators.And
+		}
+		return fmt.Errorf("only %s rule sets can be exported, got %s", logicaloperators.Or, operator)
+	}
+	if len(ruleSet.Actions) > 0 {
+		return errors.New("rule sets with actions cannot be exported")
+	}
+	for _, nestedRule := range ruleSet.Rules {
+		if r, ok := nestedRule.(map[string]interface{}); ok {
+			if _, isRuleSet := r["rules"]; isRuleSet {
+				return errors.New("nested rule sets cannot be exported")
+			}
+		}
+	}
+	err := walkRuleSet(ruleSet, func(rule Rule) error {
+		if rule.Condition.LogicalOperator != "" && rule.Condition.LogicalOperator != logicaloperators.And {
+			return fmt.Errorf("rule id #%d: only rules combining predicates with %s can be exported", rule.ID, logicaloperators.And)
+		}
+		row := make(map[columnKey]interface{})
+		for _, condition := range rule.Condition.Conditions {
+			if isGroup(condition) || condition.Quantifier != "" || condition.Window != nil || condition.Name == "" {
+				return fmt.Errorf("rule id #%d: only rules combining predicates with %s can be exported", rule.ID, logicaloperators.And)
+			}
+			key := columnKey{name: condition.Name, operator: condition.Operator}
+			if _, ok := row[key]; ok {
+				return fmt.Errorf("rule id #%d: %s %s appears more than once", rule.ID, condition.Name, condition.Operator)
+			}
+			known := false
+			for _, column := range columns {
+				known = known || column == key
+			}
+			if !known {
+				columns = append(columns, key)
+			}
+			if condition.Value == nil {
+				return fmt.Errorf("rule id #%d: %s %s compares with null, which tables cannot express", rule.ID, condition.Name, condition.Operator)
+			}
+			row[key] = condition.Value
+		}
+		rows = append(rows, row)
+		ids = append(ids, rule.ID)
+		return nil
+	}, func(Action) error { return nil })
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	header := []string{tableIDHeader}
+	for _, column := range columns {
+		if symbol, ok := tableHeaderSymbols[column.operator]; ok {
+			header = append(header, column.name+" "+symbol)
+		} else {
+			header = append(header, column.name)
+		}
+	}
+	if err = writer.Write(header); err != nil {
+		return err
+	}
+	for i, row := range rows {
+		record := []string{strconv.Itoa(ids[i])}
+		for _, column := range columns {
+			value, ok := row[column]
+			if !ok {
+				record = append(record, tableWildcard)
+				continue
+			}
+			text, err := formatTableCell(value)
+			if err != nil {
+				return fmt.Errorf("rule id #%d: %w", ids[i], err)
+			}
+			record = append(record, text)
+		}
+		if err = writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatTableCell writes strings as plain text unless they would read back as
+// another value, and everything else as JSON.
+func formatTableCell(value interface{}) (string, error) {
+	if text, ok := value.(string); ok {
+		if parsed, ok := parseTableValue(text).(string); ok && parsed == text && text != tableWildcard && !strings.Contains(text, ",") {
+			return text, nil
+		}
+	}
+	if values := reflect.ValueOf(value); (values.Kind() == reflect.Slice || values.Kind() == reflect.Array) && values.Len() > 0 {
+		parts := make([]string, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			part, err := formatTableCell(values.Index(i).Interface())
+			if err != nil || strings.Contains(part, ",") {
+				data, err := json.Marshal(value)
+				return string(data), err
+			}
+			parts = append(parts, part)
+		}
+		return strings.Join(parts, ","), nil
+	}
+	data, err := json.Marshal(value)
+	return string(data), err
+}
@@ -0,0 +1,266 @@
+package ruleengine
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/hit-policy"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const feeCSV = `id,channel,amount >=,country in,remark ~,=> fee
+10,web,1000,"ID,SG",^BFST,2
+20,-,,"[""MY""]",,0.5
+`
+
+func Test_ImportCSVDecisionTable(t *testing.T) {
+	table, err := ImportCSVDecisionTable(strings.NewReader(feeCSV), TableImportOptions{HitPolicy: hitpolicies.First})
+	if err != nil {
+		t.Fatalf("Error importing table: %v", err)
+	}
+	expected := DecisionTable{
+		HitPolicy: hitpolicies.First,
+		Inputs: []TableInput{
+			{Name: "channel"},
+			{Name: "amount", Operator: operators.GreaterThanEquals},
+			{Name: "country", Operator: operators.In},
+			{Name: "remark", Operator: operators.Match},
+		},
+		Outputs: []TableOutput{{Name: "fee"}},
+		Rows: []TableRow{
+			{ID: 10, Inputs: []interface{}{"web", float64(1000), []interface{}{"ID", "SG"}, "^BFST"}, Outputs: []interface{}{float64(2)}},
+			{ID: 20, Inputs: []interface{}{nil, nil, []interface{}{"MY"}, nil}, Outputs: []interface{}{0.5}},
+		},
+	}
+	if !reflect.DeepEqual(table, expected) {
+		t.Fatalf("Unexpected table.\nExpected: %+v\nGot:      %+v", expected, table)
+	}
+
+	processor, err := NewDecisionTableProcessor(table)
+	if err != nil {
+		t.Fatalf("Error creating processor: %v", err)
+	}
+	output, err := processor.Evaluate(map[string]interface{}{"channel": "web", "amount": 1500, "country": "SG", "remark": "BFST1"})
+	if err != nil || !reflect.DeepEqual(output.Rows, []int{10}) {
+		t.Errorf("Unexpected result: %+v, %v", output, err)
+	}
+}
+
+func Test_ImportCSVDecisionTable_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		row     int
+		column  int
+		message string
+	}{
+		{name: "Empty header", csv: "channel,,=> fee\nweb,1,2\n", row: 1, column: 2},
+		{name: "Invalid number", csv: "channel,amount >=\nweb,1000\n\nweb,lots\n", row: 4, column: 2},
+		{name: "Invalid pattern", csv: "remark ~\n(\n", row: 2, column: 1},
+		{name: "Duplicate id", csv: "id,channel\n1,web\n1,branch\n", row: 3, column: 1},
+		{name: "Extra cell", csv: "channel\nweb,branch\n", row: 2, column: 2},
+		{name: "Unknown operator symbol", csv: "channel,amount >==\nweb,1000\n", row: 1, column: 2, message: `column "amount >==": unknown operator >==`},
+		{name: "Misspelled operator", csv: "channel,amount gte\nweb,1000\n", row: 1, column: 2, message: `column "amount gte": unknown operator gte`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ImportCSVDecisionTable(strings.NewReader(tt.csv), TableImportOptions{})
+			var importErr *TableImportError
+			if !errors.As(err, &importErr) {
+				t.Fatalf("Expected import error, got %v", err)
+			}
+			if importErr.Row != tt.row || importErr.Column != tt.column {
+				t.Errorf("Unexpected error position. Expected: %d:%d, Got: %v", tt.row, tt.column, importErr)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func Test_parseTableHeader(t *testing.T) {
+	tests := []struct {
+		header   string
+		name     string
+		operator string
+	}{
+		{header: "amount >=", name: "amount", operator: operators.GreaterThanEquals},
+		{header: "Amount LESS_THAN", name: "Amount", operator: operators.LessThan},
+		{header: "delivery notes", name: "delivery notes"},
+		{header: "not_equals", name: "not_equals"},
+	}
+	for _, tt := range tests {
+		column, err := parseTableHeader(tt.header)
+		if err != nil {
+			t.Errorf("Header %q: unexpected error %v", tt.header, err)
+			continue
+		}
+		if column.kind != tableInputColumn || column.input.Name != tt.name || column.input.Operator != tt.operator {
+			t.Errorf("Header %q: unexpected column %+v", tt.header, column)
+		}
+	}
+}
+
+func xlsxFile(t *testing.T, parts map[string]string) *bytes.Reader {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range parts {
+		writer, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = writer.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func Test_ImportXLSXDecisionTable(t *testing.T) {
+	parts := map[string]string{
+		"xl/workbook.xml":            `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Fees" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="worksheet" Target="worksheets/fees.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>channel</t></si><si><r><t>amount</t></r><r><t xml:space="preserve"> &gt;=</t></r></si><si><t>=&gt; fee</t></si><si><t>web</t></si></sst>`,
+		"xl/worksheets/fees.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c></row>` +
+			`<row r="2"><c r="A2" t="s"><v>3</v></c><c r="B2"><v>1000</v></c><c r="C2" t="inlineStr"><is><t>high</t></is></c></row>` +
+			`<row r="4"><c r="B4"><v>abc</v></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+	file := xlsxFile(t, parts)
+	_, err := ImportXLSXDecisionTable(file, file.Size(), TableImportOptions{})
+	var importErr *TableImportError
+	if !errors.As(err, &importErr) || importErr.Row != 4 || importErr.Column != 2 {
+		t.Fatalf("Expected error at row 4, column 2, got %v", err)
+	}
+}
+
+func Test_ImportXLSXDecisionTable_CellReferences(t *testing.T) {
+	sheet := func(rows string) map[string]string {
+		return map[string]string{
+			"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + rows + `</sheetData></worksheet>`,
+		}
+	}
+
+	// Cells and rows without a reference follow the previous one.
+	file := xlsxFile(t, sheet(
+		`<row><c t="inlineStr"><is><t>channel</t></is></c><c t="inlineStr"><is><t>=&gt; fee</t></is></c></row>`+
+			`<row><c t="inlineStr"><is><t>web</t></is></c><c><v>1</v></c></row>`+
+			`<row r="4"><c r="B4"><v>2</v></c></row>`))
+	table, err := ImportXLSXDecisionTable(file, file.Size(), TableImportOptions{})
+	if err != nil {
+		t.Fatalf("Error importing table: %v", err)
+	}
+	expected := []TableRow{
+		{ID: 0, Inputs: []interface{}{"web"}, Outputs: []interface{}{float64(1)}},
+		{ID: 0, Inputs: []interface{}{nil}, Outputs: []interface{}{float64(2)}},
+	}
+	if !reflect.DeepEqual(table.Rows, expected) {
+		t.Errorf("Unexpected rows.\nExpected: %+v\nGot:      %+v", expected, table.Rows)
+	}
+
+	for _, ref := range []string{"XFE1", "ZZZZZZZZZZ1", "ZZZZZZZZZZZZZZZZZZZZ1"} {
+		file = xlsxFile(t, sheet(`<row r="1"><c r="`+ref+`" t="inlineStr"><is><t>channel</t></is></c></row>`))
+		if _, err = ImportXLSXDecisionTable(file, file.Size(), TableImportOptions{}); err == nil || !strings.Contains(err.Error(), "only 16384 columns are supported") {
+			t.Errorf("Reference %s: expected a column limit error, got %v", ref, err)
+		}
+	}
+}
+
+func Test_ExportRuleSetCSV(t *testing.T) {
+	ruleSet := RuleSet{LogicalOperator: logicaloperators.Or, Rules: []interface{}{
+		Rule{ID: 1, Condition: NewGroupCondition(logicaloperators.And,
+			NewCondition("channel", operators.Equals, "web"),
+			NewCondition("amount", operators.GreaterThanEquals, 1000),
+			NewCondition("country", operators.In, []interface{}{"ID", "SG"}))},
+		Rule{ID: 2, Condition: NewGroupCondition(logicaloperators.And,
+			NewCondition("channel", operators.Equals, "123"),
+			NewCondition("remark", operators.Match, "^A,B"))},
+	}}
+	var buf bytes.Buffer
+	if err := ExportRuleSetCSV(&buf, ruleSet); err != nil {
+		t.Fatalf("Error exporting rule set: %v", err)
+	}
+	expected := "id,channel,amount >=,country in,remark ~\n1,web,1000,\"ID,SG\",-\n2,\"\"\"123\"\"\",-,-,\"\"\"^A,B\"\"\"\n"
+	if buf.String() != expected {
+		t.Fatalf("Unexpected csv.\nExpected: %q\nGot:      %q", expected, buf.String())
+	}
+
+	table, err := ImportCSVDecisionTable(&buf, TableImportOptions{HitPolicy: hitpolicies.RuleOrder})
+	if err != nil {
+		t.Fatalf("Error importing exported table: %v", err)
+	}
+	imported, err := table.RuleSet()
+	if err != nil {
+		t.Fatalf("Error compiling imported table: %v", err)
+	}
+	input := map[string]interface{}{"channel": "123", "remark": "A,B and more"}
+	expectedResult := NewRuleEngine().RegisterRuleSet(ruleSet).Apply(input).GetResult()
+	importedResult := NewRuleEngine().RegisterRuleSet(imported).Apply(input).GetResult()
+	if !expectedResult.Valid || importedResult.Valid != expectedResult.Valid {
+		t.Errorf("Unexpected result after round trip. Expected: %+v, Got: %+v", expectedResult, importedResult)
+	}
+
+	single := RuleSet{Rules: []interface{}{Rule{ID: 1, Condition: NewGroupCondition(logicaloperators.And, NewCondition("channel", operators.Equals, "web"))}}}
+	if err := ExportRuleSetCSV(&buf, single); err != nil {
+		t.Errorf("Unexpected error exporting a single rule: %v", err)
+	}
+
+	webRule := Rule{ID: 1, Condition: NewGroupCondition(logicaloperators.And, NewCondition("channel", operators.Equals, "web"))}
+	largeRule := Rule{ID: 2, Condition: NewGroupCondition(logicaloperators.And, NewCondition("amount", operators.GreaterThan, 1000))}
+	invalid := []struct {
+		name    string
+		ruleSet RuleSet
+		wantErr string
+	}{
+		{
+			name:    "OR rule",
+			ruleSet: RuleSet{Rules: []interface{}{Rule{ID: 1, Condition: NewGroupCondition(logicaloperators.Or, NewCondition("channel", operators.Equals, "web"))}}},
+			wantErr: "only rules combining predicates with AND can be exported",
+		},
+		{
+			name:    "AND rule set",
+			ruleSet: RuleSet{Rules: []interface{}{webRule, largeRule}},
+			wantErr: "only OR rule sets can be exported, got AND",
+		},
+		{
+			name:    "Nested rule set",
+			ruleSet: RuleSet{LogicalOperator: logicaloperators.Or, Rules: []interface{}{webRule, map[string]interface{}{"rules": []interface{}{largeRule}}}},
+			wantErr: "nested rule sets cannot be exported",
+		},
+		{
+			name:    "Actions",
+			ruleSet: RuleSet{LogicalOperator: logicaloperators.Or, Rules: []interface{}{webRule}, Actions: []Action{{Type: "Compute"}}},
+			wantErr: "rule sets with actions cannot be exported",
+		},
+		{
+			name:    "Null value",
+			ruleSet: RuleSet{LogicalOperator: logicaloperators.Or, Rules: []interface{}{Rule{ID: 3, Condition: NewGroupCondition(logicaloperators.And, NewCondition("remark", operators.Equals, nil))}}},
+			wantErr: "rule id #3: remark equals compares with null",
+		},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ExportRuleSetCSV(&buf, tt.ruleSet)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_ImportCSVDecisionTable_NullCell(t *testing.T) {
+	_, err := ImportCSVDecisionTable(strings.NewReader("channel,=> fee\nnull,1\n"), TableImportOptions{})
+	var importErr *TableImportError
+	if !errors.As(err, &importErr) || importErr.Row != 2 || importErr.Column != 1 || !strings.Contains(err.Error(), "null cells are not supported") {
+		t.Errorf("Expected a null cell error at row 2, column 1, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package ruleengine
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"text/template"
+)
+
+type templateCache struct {
+	mu        sync.RWMutex
+	templates map[string]*template.Template
+}
+
+func newTemplateCache() *templateCache {
+	return &templateCache{
+		templates: make(map[string]*template.Template),
+	}
+}
+
+func (c *templateCache) get(text string) (*template.Template, error) {
+	c.mu.RLock()
+	tmpl, ok := c.templates[text]
+	c.mu.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+	tmpl, err := template.New("action").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %q: %w", text, err)
+	}
+	c.mu.Lock()
+	c.templates[text] = tmpl
+	c.mu.Unlock()
+	return tmpl, nil
+}
+
+// renderTemplate executes the template against the facts together with the rule
+// results of the current evaluation, which take precedence over facts of the same name.
+func (re *engine) renderTemplate(input map[string]interface{}, text string) (string, error) {
+	tmpl, err := re.config.templates.get(text)
+	if err != nil {
+		return "", err
+	}
+	data := cloneInput(input)
+	ruleResults := cloneInput(re.ruleResults)
+	data["rule_results"] = ruleResults
+	data["rule_ids"] = append([]string(nil), re.matchedRules...)
+	if len(re.matchedRules) > 0 {
+		data["rule_id"] = re.matchedRules[0]
+	}
+
+	var buffer bytes.Buffer
+	if err = tmpl.Execute(&buffer, data); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
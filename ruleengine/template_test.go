@@ -0,0 +1,60 @@
+package ruleengine
+
+import (
+	"testing"
+)
+
+func Test_ruleEngine_ApplyTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		ruleSet     string
+		expected    interface{}
+		expectedErr bool
+	}{
+		{
+			name:     "Render facts and rule id",
+			ruleSet:  `{"logical_operator":"OR","rules":[{"id":1,"condition":{"conditions":[{"name":"amount","operator":"less_than","value":10}]}},{"id":7,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":1000}]}}],"actions":[{"type":"Template","params":{"name":"message","template":"Transaction {{.amount}} from {{.account_number}} flagged by rule {{.rule_id}}"}}]}`,
+			expected: "Transaction 5000 from 123343242334 flagged by rule 7",
+		},
+		{
+			name:     "Render rule results",
+			ruleSet:  `{"rules":[{"id":3,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":1000}]}}],"actions":[{"type":"Template","params":{"template":"{{range .rule_ids}}#{{.}} {{end}}{{index .rule_results \"3\"}}"}}]}`,
+			expected: "#3 true",
+		},
+		{
+			name:        "Missing key",
+			ruleSet:     `{"rules":[{"id":3,"condition":{"conditions":[{"name":"amount","operator":"greater_than","value":1000}]}}],"actions":[{"type":"Template","params":{"template":"{{.unknown}}"}}]}`,
+			expected:    nil,
+			expectedErr: true,
+		},
+	}
+	input := map[string]interface{}{
+		"amount":         5000,
+		"account_number": "123343242334",
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := NewRuleEngine().RegisterJsonRuleSet(tt.ruleSet)
+			if processor.Err() != nil {
+				t.Fatalf("Error registering rule set: %v", processor.Err())
+			}
+			result := processor.Apply(input).GetResult()
+			if len(result.Actions) != 1 {
+				t.Fatalf("Unexpected action count: %d", len(result.Actions))
+			}
+			if result.Actions[0].Result != tt.expected {
+				t.Errorf("Unexpected output. Expected: %v, Got: %v", tt.expected, result.Actions[0].Result)
+			}
+			if (result.Actions[0].Error != "") != tt.expectedErr {
+				t.Errorf("Unexpected action error: %q", result.Actions[0].Error)
+			}
+		})
+	}
+}
+
+func Test_ruleEngine_RegisterInvalidTemplate(t *testing.T) {
+	ruleSet := `{"rules":[{"id":1,"condition":{}}],"actions":[{"type":"Template","params":{"template":"{{.amount"}}]}`
+	if NewRuleEngine().RegisterJsonRuleSet(ruleSet).Err() == nil {
+		t.Errorf("Expected registration error for invalid template")
+	}
+}
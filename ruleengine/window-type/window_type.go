@@ -0,0 +1,6 @@
+package windowtypes
+
+const (
+	Sliding  = "sliding"
+	Tumbling = "tumbling"
+)
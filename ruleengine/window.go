@@ -0,0 +1,151 @@
+package ruleengine
+
+import (
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/aggregate-function"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/decimal"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/window-type"
+	"time"
+)
+
+// Window aggregates the events of earlier evaluations that share the value of the
+// Key field, e.g. the number of transactions per account in the last 10 minutes.
+type Window struct {
+	Function string `json:"function"`
+	Key      string `json:"key,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Duration string `json:"duration"`
+	Type     string `json:"type,omitempty"`
+}
+
+func NewWindowCondition(window Window, operator string, value interface{}) Condition {
+	return Condition{Window: &window, Operator: operator, Value: value}
+}
+
+func (w Window) windowType() string {
+	if w.Type == "" {
+		return windowtypes.Sliding
+	}
+	return w.Type
+}
+
+// id identifies windows with the same definition, which share their events.
+func (w Window) id() string {
+	duration, err := time.ParseDuration(w.Duration)
+	if err != nil {
+		return fmt.Sprintf("%s(%s) by %s over %s %s", w.Function, w.Field, w.Key, w.Duration, w.windowType())
+	}
+	return fmt.Sprintf("%s(%s) by %s over %s %s", w.Function, w.Field, w.Key, duration, w.windowType())
+}
+
+func (w Window) validate() error {
+	switch w.Function {
+	case aggregatefunctions.Count:
+	case aggregatefunctions.Sum, aggregatefunctions.DistinctCount:
+		if w.Field == "" {
+			return fmt.Errorf("window %s requires a field", w.Function)
+		}
+	default:
+		return fmt.Errorf("unknown window function %q", w.Function)
+	}
+	duration, err := time.ParseDuration(w.Duration)
+	if err != nil {
+		return fmt.Errorf("invalid window duration %q: %w", w.Duration, err)
+	}
+	if duration <= 0 {
+		return fmt.Errorf("window duration %q must be positive", w.Duration)
+	}
+	switch w.windowType() {
+	case windowtypes.Sliding, windowtypes.Tumbling:
+	default:
+		return fmt.Errorf("unknown window type %q", w.Type)
+	}
+	return nil
+}
+
+// expiresAt is when an event recorded at now leaves the window: after the
+// duration for sliding windows and at the end of the current period for tumbling ones.
+func (w Window) expiresAt(now time.Time) time.Time {
+	duration, _ := time.ParseDuration(w.Duration)
+	if w.windowType() == windowtypes.Tumbling {
+		return now.Truncate(duration).Add(duration)
+	}
+	return now.Add(duration)
+}
+
+func (w Window) aggregate(events []WindowEvent) interface{} {
+	switch w.Function {
+	case aggregatefunctions.Sum:
+		sum := decimal.NewFromInt(0)
+		for _, event := range events {
+			if value, ok := toDecimal(event.Value); ok {
+				sum = sum.Add(value)
+			}
+		}
+		return sum
+	case aggregatefunctions.DistinctCount:
+		seen := make(map[string]bool, len(events))
+		for _, event := range events {
+			if event.Value != nil {
+				seen[valueKey(event.Value)] = true
+			}
+		}
+		return len(seen)
+	}
+	return len(events)
+}
+
+func (re *engine) prepareWindow(rule Rule, condition Condition) error {
+	if err := condition.Window.validate(); err != nil {
+		return fmt.Errorf("rule id #%d: %w", rule.ID, err)
+	}
+	switch condition.Operator {
+	case operators.Equals, operators.NotEquals, operators.GreaterThan, operators.GreaterThanEquals, operators.LessThan, operators.LessThanEquals:
+	default:
+		return fmt.Errorf("rule id #%d: window %s requires a comparison operator", rule.ID, condition.Window.Function)
+	}
+	if _, ok := toDecimal(condition.Value); !ok {
+		return fmt.Errorf("rule id #%d: window %s must be compared with a number, got %v", rule.ID, condition.Window.Function, condition.Value)
+	}
+	id := condition.Window.id()
+	for _, window := range re.windows {
+		if window.id() == id {
+			return nil
+		}
+	}
+	re.windows = append(re.windows, *condition.Window)
+	return nil
+}
+
+// recordWindows adds the input as an event to every window of the rule set before
+// evaluation, so conditions see the aggregates regardless of evaluation order.
+// Inputs without the key field are not recorded and their window conditions are false.
+func (re *engine) recordWindows(input map[string]interface{}) error {
+	re.windowValues = make(map[string]interface{}, len(re.windows))
+	if len(re.windows) == 0 {
+		return nil
+	}
+	now := re.config.clock()
+	for _, window := range re.windows {
+		id := window.id()
+		key := id
+		if window.Key != "" {
+			value, ok := input[window.Key]
+			if !ok || value == nil {
+				continue
+			}
+			key = fmt.Sprintf("%s|%s", id, valueKey(value))
+		}
+		event := WindowEvent{Time: now, ExpiresAt: window.expiresAt(now)}
+		if window.Field != "" {
+			event.Value = input[window.Field]
+		}
+		events, err := re.config.stateStore.Append(key, event, now)
+		if err != nil {
+			return fmt.Errorf("window %s: %w", id, err)
+		}
+		re.windowValues[id] = window.aggregate(events)
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package ruleengine
+
+import (
+	"fmt"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/aggregate-function"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/logical-operator"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/operators"
+	"github.com/ahmadrezamusthafa/rule-engine/ruleengine/window-type"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func windowRuleSet(window Window, operator string, value interface{}) RuleSet {
+	return RuleSet{Rules: []interface{}{Rule{ID: 1, Condition: NewGroupCondition(logicaloperators.And, NewWindowCondition(window, operator, value))}}}
+}
+
+func Test_ruleEngine_ApplyWindow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	type step struct {
+		offset   time.Duration
+		input    map[string]interface{}
+		expected bool
+	}
+	tests := []struct {
+		name     string
+		window   Window
+		operator string
+		value    interface{}
+		steps    []step
+	}{
+		{
+			name:     "Sliding count per account",
+			window:   Window{Function: aggregatefunctions.Count, Key: "account", Duration: "10m"},
+			operator: operators.GreaterThan,
+			value:    2,
+			steps: []step{
+				{offset: 0, input: map[string]interface{}{"account": "A"}, expected: false},
+				{offset: time.Minute, input: map[string]interface{}{"account": "A"}, expected: false},
+				{offset: 2 * time.Minute, input: map[string]interface{}{"account": "B"}, expected: false},
+				{offset: 3 * time.Minute, input: map[string]interface{}{"account": "A"}, expected: true},
+				{offset: 10 * time.Minute, input: map[string]interface{}{"account": "A"}, expected: true},
+				{offset: 14 * time.Minute, input: map[string]interface{}{"account": "A"}, expected: false},
+				{offset: 14 * time.Minute, input: map[string]interface{}{}, expected: false},
+			},
+		},
+		{
+			name:     "Tumbling sum",
+			window:   Window{Function: aggregatefunctions.Sum, Key: "account", Field: "amount", Duration: "1h", Type: windowtypes.Tumbling},
+			operator: operators.GreaterThanEquals,
+			value:    1000,
+			steps: []step{
+				{offset: 0, input: map[string]interface{}{"account": "A", "amount": 600}, expected: false},
+				{offset: 50 * time.Minute, input: map[string]interface{}{"account": "A", "amount": "400"}, expected: true},
+				{offset: 61 * time.Minute, input: map[string]interface{}{"account": "A", "amount": 100}, expected: false},
+			},
+		},
+		{
+			name:     "Distinct devices",
+			window:   Window{Function: aggregatefunctions.DistinctCount, Key: "account", Field: "device", Duration: "24h"},
+			operator: operators.Equals,
+			value:    2,
+			steps: []step{
+				{offset: 0, input: map[string]interface{}{"account": 1, "device": "d1"}, expected: false},
+				{offset: time.Hour, input: map[string]interface{}{"account": 1.0, "device": "d1"}, expected: false},
+				{offset: 2 * time.Hour, input: map[string]interface{}{"account": 1, "device": "d2"}, expected: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &testClock{now: start}
+			processor := NewRuleEngine(WithClock(clock.Now)).RegisterRuleSet(windowRuleSet(tt.window, tt.operator, tt.value))
+			if processor.Err() != nil {
+				t.Fatalf("Error registering rule set: %v", processor.Err())
+			}
+			for i, step := range tt.steps {
+				clock.now = start.Add(step.offset)
+				output := processor.Apply(step.input).GetResult()
+				if output.Error != "" {
+					t.Fatalf("Error applying step %d: %v", i, output.Error)
+				}
+				if output.Valid != step.expected {
+					t.Errorf("Unexpected output at step %d. Expected: %v, Got: %v", i, step.expected, output.Valid)
+				}
+			}
+		})
+	}
+}
+
+func Test_FileStateStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	clock := &testClock{now: start}
+	ruleSet := windowRuleSet(Window{Function: aggregatefunctions.Count, Key: "account", Duration: "10m"}, operators.Equals, 2)
+
+	store, err := NewFileStateStore(path)
+	if err != nil {
+		t.Fatalf("Error opening store: %v", err)
+	}
+	defer store.Close()
+	if NewRuleEngine(WithClock(clock.Now), WithStateStore(store)).RegisterRuleSet(ruleSet).Apply(map[string]interface{}{"account": "A"}).GetResult().Valid {
+		t.Errorf("Expected first event not to match")
+	}
+
+	reopened, err := NewFileStateStore(path)
+	if err != nil {
+		t.Fatalf("Error reopening store: %v", err)
+	}
+	defer reopened.Close()
+	clock.now = start.Add(time.Minute)
+	if !NewRuleEngine(WithClock(clock.Now), WithStateStore(reopened)).RegisterRuleSet(ruleSet).Apply(map[string]interface{}{"account": "A"}).GetResult().Valid {
+		t.Errorf("Expected persisted event to be counted")
+	}
+
+	if err = reopened.Expire(start.Add(time.Hour)); err != nil {
+		t.Fatalf("Error expiring events: %v", err)
+	}
+	if len(reopened.memory.events) != 0 {
+		t.Errorf("Expected expired events to be removed, got %v", reopened.memory.events)
+	}
+}
+
+func Test_FileStateStore_Compaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	store, err := NewFileStateStore(path)
+	if err != nil {
+		t.Fatalf("Error opening store: %v", err)
+	}
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 10*fileStateCompactionMin; i++ {
+		now := start.Add(time.Duration(i) * time.Second)
+		event := WindowEvent{Time: now, ExpiresAt: now.Add(time.Minute), Value: i}
+		if _, err = store.Append(fmt.Sprintf("account|%d", i%3), event, now); err != nil {
+			t.Fatalf("Error appending event: %v", err)
+		}
+	}
+	if store.records > 2*fileStateCompactionMin {
+		t.Errorf("Expected the log to be compacted, got %d records", store.records)
+	}
+	if err = store.Close(); err != nil {
+		t.Fatalf("Error closing store: %v", err)
+	}
+
+	// A record cut short by a crash is dropped and later records still replay.
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatalf("Error opening log: %v", err)
+	}
+	_, _ = file.WriteString(`{"key":"account|0","event":{"ti`)
+	_ = file.Close()
+
+	reopened, err := NewFileStateStore(path)
+	if err != nil {
+		t.Fatalf("Error reopening store: %v", err)
+	}
+	if !reflect.DeepEqual(valuesByKey(reopened.memory), valuesByKey(store.memory)) {
+		t.Errorf("Reopened store differs.\nExpected: %v\nGot:      %v", valuesByKey(store.memory), valuesByKey(reopened.memory))
+	}
+	now := start.Add(10 * time.Hour)
+	if _, err = reopened.Append("account|0", WindowEvent{Time: now, ExpiresAt: now.Add(time.Minute)}, now); err != nil {
+		t.Fatalf("Error appending event: %v", err)
+	}
+	_ = reopened.Close()
+	again, err := NewFileStateStore(path)
+	if err != nil {
+		t.Fatalf("Error reopening store: %v", err)
+	}
+	defer again.Close()
+	if events := again.memory.events["account|0"]; len(events) != 1 || !events[0].Time.Equal(now) {
+		t.Errorf("Expected only the event appended after the crash, got %v", events)
+	}
+}
+
+func Test_StateStore_ExpiresIdleKeys(t *testing.T) {
+	fileStore, err := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Error opening store: %v", err)
+	}
+	defer fileStore.Close()
+	tests := []struct {
+		name   string
+		store  StateStore
+		memory *MemoryStateStore
+	}{
+		{name: "Memory", store: NewMemoryStateStore()},
+		{name: "File", store: fileStore, memory: fileStore.memory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := tt.memory
+			if memory == nil {
+				memory = tt.store.(*MemoryStateStore)
+			}
+			start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+			for i := 0; i < 100; i++ {
+				event := WindowEvent{Time: start, ExpiresAt: start.Add(time.Minute)}
+				if _, err := tt.store.Append(fmt.Sprintf("card|%d", i), event, start); err != nil {
+					t.Fatalf("Error appending event: %v", err)
+				}
+			}
+			// Only one key keeps receiving events after the others expired.
+			for i := 0; i < 2*fileStateCompactionMin; i++ {
+				now := start.Add(time.Hour + time.Duration(i)*time.Second)
+				event := WindowEvent{Time: now, ExpiresAt: now.Add(time.Minute)}
+				if _, err := tt.store.Append("card|busy", event, now); err != nil {
+					t.Fatalf("Error appending event: %v", err)
+				}
+			}
+			memory.mu.Lock()
+			defer memory.mu.Unlock()
+			if len(memory.events) != 1 {
+				t.Errorf("Expected only the busy key to be kept, got %d keys", len(memory.events))
+			}
+		})
+	}
+	data, err := os.ReadFile(fileStore.path)
+	if err != nil {
+		t.Fatalf("Error reading log: %v", err)
+	}
+	if strings.Contains(string(data), `"card|0"`) {
+		t.Errorf("Expected the compacted log to drop the idle keys")
+	}
+}
+
+// valuesByKey lists the event values of each key, as JSON decodes them.
+func valuesByKey(store *MemoryStateStore) map[string][]string {
+	values := make(map[string][]string)
+	for key, events := range store.events {
+		for _, event := range events {
+			values[key] = append(values[key], fmt.Sprint(event.Value))
+		}
+	}
+	return values
+}
+
+func Test_Window_validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		window Window
+		valid  bool
+	}{
+		{name: "Count", window: Window{Function: aggregatefunctions.Count, Duration: "5m"}, valid: true},
+		{name: "Sum without field", window: Window{Function: aggregatefunctions.Sum, Duration: "5m"}},
+		{name: "Unknown function", window: Window{Function: aggregatefunctions.Avg, Field: "amount", Duration: "5m"}},
+		{name: "Invalid duration", window: Window{Function: aggregatefunctions.Count, Duration: "soon"}},
+		{name: "Unknown type", window: Window{Function: aggregatefunctions.Count, Duration: "5m", Type: "hopping"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewRuleEngine().RegisterRuleSet(windowRuleSet(tt.window, operators.GreaterThan, 1)).Err()
+			if (err == nil) != tt.valid {
+				t.Errorf("Unexpected registration error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package ruleengine
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	xlsxWorkbook      = "xl/workbook.xml"
+	xlsxWorkbookRels  = "xl/_rels/workbook.xml.rels"
+	xlsxSharedStrings = "xl/sharedStrings.xml"
+	xlsxFirstSheet    = "xl/worksheets/sheet1.xml"
+
+	// xlsxMaxColumns is the number of columns of a sheet, up to column XFD.
+	xlsxMaxColumns = 16384
+)
+
+type xlsxWorkbookXML struct {
+	Sheets []struct {
+		ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationshipsXML struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type xlsxSharedStringsXML struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxSheetXML struct {
+	Rows []struct {
+		Ref   int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ImportXLSXDecisionTable reads a decision table from the first sheet of an XLSX
+// file, laid out like the CSV format of ImportCSVDecisionTable. Cells are read as
+// their stored values; formulas are not evaluated.
+func ImportXLSXDecisionTable(r io.ReaderAt, size int64, opts TableImportOptions) (DecisionTable, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return DecisionTable{}, fmt.Errorf("invalid xlsx file: %w", err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	var sharedStrings xlsxSharedStringsXML
+	if file, ok := files[xlsxSharedStrings]; ok {
+		if err = decodeXLSXPart(file, &sharedStrings); err != nil {
+			return DecisionTable{}, err
+		}
+	}
+	sheetFile, ok := files[firstXLSXSheet(files)]
+	if !ok {
+		return DecisionTable{}, errors.New("invalid xlsx file: no worksheet")
+	}
+	var sheet xlsxSheetXML
+	if err = decodeXLSXPart(sheetFile, &sheet); err != nil {
+		return DecisionTable{}, err
+	}
+
+	// Rows and cells without a reference follow the previous one.
+	var records []tableRecord
+	previousRow := 0
+	for _, row := range sheet.Rows {
+		rowNumber := row.Ref
+		if rowNumber == 0 {
+			rowNumber = previousRow + 1
+		}
+		previousRow = rowNumber
+		var record []string
+		column := -1
+		for _, cell := range row.Cells {
+			column++
+			if cell.Ref != "" {
+				if column, err = xlsxColumnIndex(cell.Ref); err != nil {
+					return DecisionTable{}, err
+				}
+			}
+			if column >= xlsxMaxColumns {
+				return DecisionTable{}, fmt.Errorf("row %d: only %d columns are supported", rowNumber, xlsxMaxColumns)
+			}
+			for len(record) <= column {
+				record = append(record, "")
+			}
+			text := cell.Value
+			switch cell.Type {
+			case "s":
+				var index int
+				if _, err = fmt.Sscan(cell.Value, &index); err != nil || index < 0 || index >= len(sharedStrings.Items) {
+					return DecisionTable{}, fmt.Errorf("cell %s: invalid shared string %q", cell.Ref, cell.Value)
+				}
+				text = sharedStrings.Items[index].String()
+			case "inlineStr":
+				text = cell.Inline.String()
+			case "b":
+				text = "false"
+				if cell.Value == "1" {
+					text = "true"
+				}
+			}
+			record[column] = text
+		}
+		records = append(records, tableRecord{row: rowNumber, cells: record})
+	}
+	return decisionTableFromRecords(records, opts)
+}
+
+// firstXLSXSheet follows the workbook relationships to the first sheet, falling
+// back to the conventional sheet1.xml.
+func firstXLSXSheet(files map[string]*zip.File) string {
+	workbookFile, ok := files[xlsxWorkbook]
+	relsFile, hasRels := files[xlsxWorkbookRels]
+	if !ok || !hasRels {
+		return xlsxFirstSheet
+	}
+	var (
+		workbook xlsxWorkbookXML
+		rels     xlsxRelationshipsXML
+	)
+	if decodeXLSXPart(workbookFile, &workbook) != nil || decodeXLSXPart(relsFile, &rels) != nil || len(workbook.Sheets) == 0 {
+		return xlsxFirstSheet
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[0].ID {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/")
+			}
+			return path.Join("xl", rel.Target)
+		}
+	}
+	return xlsxFirstSheet
+}
+
+func decodeXLSXPart(file *zip.File, v interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if err = xml.NewDecoder(reader).Decode(v); err != nil {
+		return fmt.Errorf("invalid xlsx part %s: %w", file.Name, err)
+	}
+	return nil
+}
+
+// xlsxColumnIndex converts the letters of a cell reference such as "AB12" to a
+// column index from 0.
+func xlsxColumnIndex(ref string) (int, error) {
+	column := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+		letters++
+		if column > xlsxMaxColumns {
+			return 0, fmt.Errorf("cell %s: only %d columns are supported", ref, xlsxMaxColumns)
+		}
+	}
+	if letters == 0 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return column - 1, nil
+}